@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestParallelSumDeterministic(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 256)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	const count = 2000
+	ciphers := encryptRange(t, pub, count)
+
+	//serial reference fold
+	want := big.NewInt(1)
+	for _, c := range ciphers {
+		want.Mod(want.Mul(want, new(big.Int).SetBytes(c)), pub.Nsq)
+	}
+
+	for i := 0; i < 50; i++ {
+		if got := gaillier.Sum(pub, ciphers); !bytes.Equal(got, want.Bytes()) {
+			t.Fatalf("Run %d : parallel Sum differs from the serial fold", i)
+		}
+	}
+
+	d, _ := gaillier.Decrypt(priv, want.Bytes())
+	if got := new(big.Int).SetBytes(d).Int64(); got != count*(count-1)/2 {
+		t.Errorf("Sum decrypted to %d want %d", got, count*(count-1)/2)
+	}
+}
+
+func TestDotProduct(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 256)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	const count = 200
+	ciphers := encryptRange(t, pub, count)
+	weights := make([][]byte, count)
+	var expected int64
+	for i := range weights {
+		weights[i] = big.NewInt(int64(i % 7)).Bytes()
+		expected += int64(i) * int64(i%7)
+	}
+
+	first, err := gaillier.DotProduct(pub, ciphers, weights)
+	if err != nil {
+		t.Fatalf("DotProduct failed %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		got, _ := gaillier.DotProduct(pub, ciphers, weights)
+		if !bytes.Equal(got, first) {
+			t.Fatalf("Run %d : parallel DotProduct is not deterministic", i)
+		}
+	}
+
+	d, _ := gaillier.Decrypt(priv, first)
+	if got := new(big.Int).SetBytes(d).Int64(); got != expected {
+		t.Errorf("DotProduct decrypted to %d want %d", got, expected)
+	}
+
+	if _, err := gaillier.DotProduct(pub, ciphers, weights[1:]); err != gaillier.ErrDimensionMismatch {
+		t.Errorf("Mismatched DotProduct got %v want %v", err, gaillier.ErrDimensionMismatch)
+	}
+}
+
+func TestMatVec(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	signed := func(v int64) []byte {
+		b, err := gaillier.EncodeSigned(pub, big.NewInt(v))
+		if err != nil {
+			t.Fatalf("Error EncodeSigned %v", err)
+		}
+		return b
+	}
+
+	//A.x + b with x = (3, -2, 5)
+	a := [][]int64{{1, 2, 3}, {-4, 0, 1}, {2, -1, -1}}
+	bias := []int64{10, -7, 0}
+	want := []int64{10 + 3 - 4 + 15, -7 - 12 + 5, 6 + 2 - 5}
+
+	x := make([][]byte, 3)
+	for i, v := range []int64{3, -2, 5} {
+		x[i], err = gaillier.EncryptInt64(pub, v)
+		if err != nil {
+			t.Fatalf("Error EncryptInt64 %v", err)
+		}
+	}
+	A := make([][][]byte, len(a))
+	b := make([][]byte, len(bias))
+	for i, row := range a {
+		A[i] = make([][]byte, len(row))
+		for j, v := range row {
+			A[i][j] = signed(v)
+		}
+		b[i] = signed(bias[i])
+	}
+
+	out, err := gaillier.MatVec(pub, A, x, b)
+	if err != nil {
+		t.Fatalf("Error MatVec %v", err)
+	}
+	for i, c := range out {
+		got, _ := gaillier.DecryptInt64(priv, c)
+		if got != want[i] {
+			t.Errorf("Error MatVec row %d got %v want %v", i, got, want[i])
+		}
+	}
+
+	if _, err := gaillier.MatVec(pub, A, x[1:], b); !errors.Is(err, gaillier.ErrDimensionMismatch) {
+		t.Errorf("Error MatVec short vector got %v want ErrDimensionMismatch", err)
+	}
+	if _, err := gaillier.MatVec(pub, A, x, b[1:]); !errors.Is(err, gaillier.ErrDimensionMismatch) {
+		t.Errorf("Error MatVec short bias got %v want ErrDimensionMismatch", err)
+	}
+}
+
+func TestPrefixSum(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	ciphers := encryptRange(t, pub, 10)
+
+	plain, err := gaillier.PrefixSum(pub, ciphers)
+	if err != nil {
+		t.Fatalf("Error PrefixSum %v", err)
+	}
+	randomized, err := gaillier.PrefixSumReRandomized(pub, ciphers)
+	if err != nil {
+		t.Fatalf("Error PrefixSumReRandomized %v", err)
+	}
+
+	var want int64
+	for i := range ciphers {
+		want += int64(i)
+		for name, out := range map[string][][]byte{"PrefixSum": plain, "PrefixSumReRandomized": randomized} {
+			d, _ := gaillier.Decrypt(priv, out[i])
+			if got := new(big.Int).SetBytes(d).Int64(); got != want {
+				t.Errorf("Error %s prefix %d got %v want %v", name, i, got, want)
+			}
+		}
+		if bytes.Equal(plain[i], randomized[i]) {
+			t.Errorf("Error re-randomized prefix %d equals the plain one", i)
+		}
+	}
+	if !bytes.Equal(plain[0], ciphers[0]) {
+		t.Errorf("Error first prefix is not the first cipher")
+	}
+
+	if _, err := gaillier.PrefixSum(pub, nil); !errors.Is(err, gaillier.ErrDimensionMismatch) {
+		t.Errorf("Error PrefixSum of nothing got %v want ErrDimensionMismatch", err)
+	}
+}
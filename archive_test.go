@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestArchive(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	values := map[string]int64{"alice": 10, "bob": 20, "carol": 0, "": 7}
+	named := make(map[string][]byte)
+	for name, v := range values {
+		named[name] = mustEncrypt(t, pub, big.NewInt(v).Bytes())
+	}
+
+	buf := new(bytes.Buffer)
+	if err := gaillier.WriteArchive(buf, priv, named); err != nil {
+		t.Fatalf("WriteArchive failed %v", err)
+	}
+	data := buf.Bytes()
+
+	loaded, ciphers, err := gaillier.ReadArchive(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadArchive failed %v", err)
+	}
+	if loaded.Fingerprint() != pub.Fingerprint() || len(ciphers) != len(values) {
+		t.Fatalf("Error ReadArchive returned another key or %v entries", len(ciphers))
+	}
+	for name, v := range values {
+		d, err := gaillier.Decrypt(loaded, ciphers[name])
+		if err != nil || new(big.Int).SetBytes(d).Int64() != v {
+			t.Errorf("Error entry %q got %v want %v (%v)", name, d, v, err)
+		}
+	}
+
+	if _, _, err := gaillier.ReadArchive(bytes.NewReader(data[:len(data)-10])); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+		t.Errorf("Error truncated archive got %v want ErrInvalidEncoding", err)
+	}
+	if _, _, err := gaillier.ReadArchive(bytes.NewReader([]byte("nope, not an archive"))); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+		t.Errorf("Error bad magic got %v want ErrInvalidEncoding", err)
+	}
+}
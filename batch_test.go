@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func encryptRange(t *testing.T, pub *gaillier.PubKey, count int) [][]byte {
+	ciphers := make([][]byte, count)
+	for i := range ciphers {
+		c, err := gaillier.Encrypt(pub, big.NewInt(int64(i)).Bytes())
+		if err != nil {
+			t.Fatalf("Failed to encrypt %v", err)
+		}
+		ciphers[i] = c
+	}
+	return ciphers
+}
+
+func TestDecryptBatchLimited(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 1024)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	ciphers := encryptRange(t, pub, 64)
+
+	//sample the number of goroutines while the batch runs
+	var peak int64
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if n := int64(runtime.NumGoroutine()); n > atomic.LoadInt64(&peak) {
+				atomic.StoreInt64(&peak, n)
+			}
+			runtime.Gosched()
+		}
+	}()
+	baseline := runtime.NumGoroutine()
+
+	const maxConcurrency = 3
+	res, err := gaillier.DecryptBatchLimited(context.Background(), priv, ciphers, maxConcurrency)
+	close(stop)
+	<-done
+
+	if err != nil {
+		t.Fatalf("DecryptBatchLimited failed %v", err)
+	}
+	for i, m := range res {
+		if new(big.Int).SetBytes(m).Int64() != int64(i) {
+			t.Errorf("Item %d decrypted to %v", i, new(big.Int).SetBytes(m))
+		}
+	}
+	if extra := int(peak) - baseline; extra > maxConcurrency {
+		t.Errorf("Batch ran %d goroutines, cap is %d", extra, maxConcurrency)
+	}
+}
+
+func TestDecryptBatchLimitedCancel(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 1024)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	ciphers := encryptRange(t, pub, 8)
+
+	//already cancelled : nothing is decrypted
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res, err := gaillier.DecryptBatchLimited(ctx, priv, ciphers, 2)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Cancelled batch got %v want %v", err, context.Canceled)
+	}
+	for i, m := range res {
+		if m != nil {
+			t.Errorf("Item %d decrypted after cancellation", i)
+		}
+	}
+
+	//cancelled half way through a long batch : returns promptly with partial results
+	long := make([][]byte, 20000)
+	for i := range long {
+		long[i] = ciphers[i%len(ciphers)]
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	res, err = gaillier.DecryptBatchLimited(ctx, priv, long, 2)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Timed out batch got %v want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed > time.Second {
+		t.Errorf("Cancellation took %v", elapsed)
+	}
+	if res[len(res)-1] != nil {
+		t.Errorf("Last item decrypted despite cancellation")
+	}
+	if res[1] == nil || new(big.Int).SetBytes(res[1]).Int64() != 1 {
+		t.Errorf("Early item missing from partial results")
+	}
+}
+
+func TestReRandomizeBatch(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	ciphers := encryptRange(t, pub, 100)
+	out, err := gaillier.ReRandomizeBatch(pub, ciphers)
+	if err != nil {
+		t.Fatalf("ReRandomizeBatch failed %v", err)
+	}
+	if len(out) != len(ciphers) {
+		t.Fatalf("Error ReRandomizeBatch returned %v ciphers want %v", len(out), len(ciphers))
+	}
+
+	for i := range ciphers {
+		if bytes.Equal(out[i], ciphers[i]) {
+			t.Errorf("Error cipher %d was not re-randomized", i)
+		}
+		d, _ := gaillier.Decrypt(priv, out[i])
+		if got := new(big.Int).SetBytes(d); got.Int64() != int64(i) {
+			t.Errorf("Error cipher %d decrypts to %v", i, got)
+		}
+	}
+}
+
+func benchmarkReRandomize(b *testing.B, batch bool) {
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 1024)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ciphers := make([][]byte, 64)
+	for i := range ciphers {
+		ciphers[i], _ = gaillier.Encrypt(pub, big.NewInt(int64(i)).Bytes())
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if batch {
+			gaillier.ReRandomizeBatch(pub, ciphers)
+			continue
+		}
+		for _, c := range ciphers {
+			gaillier.ReRandomize(pub, c)
+		}
+	}
+}
+
+func BenchmarkReRandomizeSerial(b *testing.B) { benchmarkReRandomize(b, false) }
+
+func BenchmarkReRandomizeBatch(b *testing.B) { benchmarkReRandomize(b, true) }
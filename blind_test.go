@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestBlindedDecryption(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	for _, v := range []int64{0, 7, 1 << 50} {
+		m := big.NewInt(v).Bytes()
+		c, _ := gaillier.Encrypt(pub, m)
+
+		// client side
+		blinded, mask, err := gaillier.BlindForDecryption(pub, c)
+		if err != nil {
+			t.Fatalf("BlindForDecryption failed %v", err)
+		}
+
+		// server side, sees only m + mask
+		seen, err := gaillier.Decrypt(priv, blinded)
+		if err != nil {
+			t.Fatalf("Decrypt failed %v", err)
+		}
+		if v != 0 && bytes.Equal(seen, m) {
+			t.Errorf("Error server saw the plaintext")
+		}
+
+		// client side
+		if got := gaillier.Unblind(seen, mask, pub); !bytes.Equal(got, m) {
+			t.Errorf("Error Unblind got %v want %v", new(big.Int).SetBytes(got), v)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestBoundedAdd(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	acc, err := gaillier.EncryptBounded(pub, []byte{10})
+	if err != nil {
+		t.Fatalf("EncryptBounded failed %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		x, _ := gaillier.EncryptBounded(pub, []byte{10})
+		if acc, err = gaillier.AddBounded(pub, acc, x); err != nil {
+			t.Fatalf("AddBounded failed %v", err)
+		}
+	}
+	acc, _ = gaillier.AddConstantBounded(pub, acc, []byte{4})
+	acc, _ = gaillier.MulBounded(pub, acc, []byte{2})
+
+	if acc.Bound.Int64() != 128 {
+		t.Errorf("Error bound got %v want 128", acc.Bound)
+	}
+	if d, _ := gaillier.Decrypt(priv, acc.Cipher); new(big.Int).SetBytes(d).Int64() != 128 {
+		t.Errorf("Error bounded cipher got %v want 128", new(big.Int).SetBytes(d))
+	}
+}
+
+func TestBoundedOverflow(t *testing.T) {
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	// a quarter of n, the fourth addition reaches n
+	quarter := new(big.Int).Rsh(pub.N, 2)
+	x, _ := gaillier.EncryptBounded(pub, quarter.Bytes())
+
+	acc := x
+	for i := 1; i < 4; i++ {
+		if acc, err = gaillier.AddBounded(pub, acc, x); err != nil {
+			t.Fatalf("AddBounded failed after %d additions %v", i, err)
+		}
+	}
+	if _, err := gaillier.AddBounded(pub, acc, x); !errors.Is(err, gaillier.ErrOverflow) {
+		t.Errorf("Error AddBounded past n got %v want ErrOverflow", err)
+	}
+	if _, err := gaillier.MulBounded(pub, x, []byte{5}); !errors.Is(err, gaillier.ErrOverflow) {
+		t.Errorf("Error MulBounded past n got %v want ErrOverflow", err)
+	}
+}
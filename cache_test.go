@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestDecryptCacheHit(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	cache := gaillier.NewDecryptCache(priv, 4)
+	c := mustEncrypt(t, pub, []byte{42})
+
+	// count exponentiations to tell hits from misses
+	var calls atomic.Int64
+	prev := gaillier.SetModExp(func(base, exp, mod *big.Int) *big.Int {
+		calls.Add(1)
+		return new(big.Int).Exp(base, exp, mod)
+	})
+	defer gaillier.SetModExp(prev)
+
+	for i := 0; i < 3; i++ {
+		d, err := cache.Decrypt(c)
+		if err != nil || new(big.Int).SetBytes(d).Int64() != 42 {
+			t.Errorf("Error cached Decrypt got %v want 42 (%v)", d, err)
+		}
+		d[0] = 0 // callers may modify the result
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("Error decrypted %v times want 1", got)
+	}
+
+	cache.Clear()
+	if cache.Len() != 0 {
+		t.Errorf("Error Len after Clear got %v want 0", cache.Len())
+	}
+	if d, _ := cache.Decrypt(c); new(big.Int).SetBytes(d).Int64() != 42 || calls.Load() != 2 {
+		t.Errorf("Error Decrypt after Clear got %v want a fresh 42", d)
+	}
+}
+
+func TestDecryptCacheEviction(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	cache := gaillier.NewDecryptCache(priv, 3)
+	ciphers := encryptRange(t, pub, 5)
+
+	for i, c := range ciphers {
+		d, err := cache.Decrypt(c)
+		if err != nil || new(big.Int).SetBytes(d).Int64() != int64(i) {
+			t.Errorf("Error cached Decrypt got %v want %v (%v)", d, i, err)
+		}
+		if want := min(i+1, 3); cache.Len() != want {
+			t.Errorf("Error Len got %v want %v", cache.Len(), want)
+		}
+	}
+
+	var calls atomic.Int64
+	prev := gaillier.SetModExp(func(base, exp, mod *big.Int) *big.Int {
+		calls.Add(1)
+		return new(big.Int).Exp(base, exp, mod)
+	})
+	defer gaillier.SetModExp(prev)
+
+	// the last three are cached, the first was evicted
+	for _, c := range ciphers[2:] {
+		cache.Decrypt(c)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("Error recent ciphers were not served from the cache")
+	}
+	cache.Decrypt(ciphers[0])
+	if calls.Load() != 1 {
+		t.Errorf("Error evicted cipher was served from the cache")
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/rand"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestAdditiveHeadroom(t *testing.T) {
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	nMin := new(big.Int).Sub(pub.N, big.NewInt(1))
+	addend := big.NewInt(10)
+
+	cases := []struct {
+		current *big.Int
+		addend  *big.Int
+		want    int
+	}{
+		//exactly one more addition fits
+		{new(big.Int).Sub(nMin, addend), addend, 1},
+		//one short of a single addition
+		{new(big.Int).Sub(nMin, big.NewInt(9)), addend, 0},
+		//already at the top of the ring
+		{nMin, addend, 0},
+		//past the top
+		{pub.N, addend, 0},
+		//three additions and a remainder
+		{new(big.Int).Sub(nMin, big.NewInt(35)), addend, 3},
+		//zero addends never wrap
+		{big.NewInt(0), big.NewInt(0), math.MaxInt},
+		//result too large for an int
+		{big.NewInt(0), big.NewInt(1), math.MaxInt},
+	}
+
+	for i, c := range cases {
+		if got := pub.AdditiveHeadroom(c.current, c.addend); got != c.want {
+			t.Errorf("Case %d AdditiveHeadroom got %d want %d", i, got, c.want)
+		}
+	}
+}
+
+func TestCiphertextBits(t *testing.T) {
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 1024)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	//n is exactly 1024 bits so n^2 has 2047 or 2048 bits
+	if got := pub.CiphertextBits(); got != 2048 && got != 2047 {
+		t.Errorf("CiphertextBits got %d want 2047 or 2048", got)
+	}
+	if got := pub.CiphertextBits(); got != pub.Nsq.BitLen() {
+		t.Errorf("CiphertextBits got %d want %d", got, pub.Nsq.BitLen())
+	}
+
+	bits := float64(pub.CiphertextBits())
+	cases := []struct {
+		plaintextBits int
+		want          float64
+	}{
+		{64, bits / 64},
+		{1024, bits / 1024},
+		{0, 0},
+		{-8, 0},
+	}
+	for _, c := range cases {
+		if got := pub.ExpansionFactor(c.plaintextBits); got != c.want {
+			t.Errorf("ExpansionFactor(%d) got %v want %v", c.plaintextBits, got, c.want)
+		}
+	}
+}
+
+func TestMaxAdditions(t *testing.T) {
+
+	//n = 61*53 = 3233
+	small, err := gaillier.NewPubKeyFromN(big.NewInt(3233))
+	if err != nil {
+		t.Fatalf("NewPubKeyFromN failed %v", err)
+	}
+
+	cases := []struct {
+		bits int
+		want int
+	}{
+		{1, 3232},
+		{4, 215},
+		//2^11-1 = 2047, a single addend fits
+		{11, 1},
+		//2^12-1 >= n, not even one addend is safe
+		{12, 0},
+		{0, math.MaxInt},
+	}
+
+	for i, c := range cases {
+		if got := small.MaxAdditions(c.bits); got != c.want {
+			t.Errorf("Case %d MaxAdditions(%d) got %d want %d", i, c.bits, got, c.want)
+		}
+	}
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	if got := pub.MaxAdditions(32); got != math.MaxInt {
+		t.Errorf("Error MaxAdditions(32) got %d want MaxInt", got)
+	}
+	if got := pub.MaxAdditions(pub.N.BitLen() - 1); got != 1 {
+		t.Errorf("Error MaxAdditions(bitlen-1) got %d want 1", got)
+	}
+	if got := pub.MaxAdditions(pub.N.BitLen()); got != 0 {
+		t.Errorf("Error MaxAdditions(bitlen) got %d want 0", got)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestCheckedPrimitives(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	c, _ := gaillier.Encrypt(pub, big.NewInt(5).Bytes())
+	k := big.NewInt(3).Bytes()
+
+	//well formed keys behave like the unchecked primitives
+	res, err := gaillier.CheckedMul(pub, c, k)
+	if err != nil {
+		t.Fatalf("CheckedMul failed %v", err)
+	}
+	d, _ := gaillier.Decrypt(priv, res)
+	if new(big.Int).SetBytes(d).Int64() != 15 {
+		t.Errorf("CheckedMul got %v want 15", new(big.Int).SetBytes(d))
+	}
+
+	noN := &gaillier.PubKey{KeyLen: pub.KeyLen, G: pub.G, Nsq: pub.Nsq}
+	noG := &gaillier.PubKey{KeyLen: pub.KeyLen, N: pub.N, Nsq: pub.Nsq}
+	badNsq := &gaillier.PubKey{KeyLen: pub.KeyLen, N: pub.N, G: pub.G, Nsq: pub.N}
+
+	for name, bad := range map[string]*gaillier.PubKey{"nil N": noN, "nil G": noG, "wrong Nsq": badNsq, "nil key": nil, "empty key": {}} {
+		checks := map[string]error{
+			"CheckedAdd": func() error { _, err := gaillier.CheckedAdd(bad, c, c); return err }(),
+			"CheckedSum": func() error { _, err := gaillier.CheckedSum(bad, [][]byte{c}); return err }(),
+			"CheckedAddConstant": func() error {
+				_, err := gaillier.CheckedAddConstant(bad, c, k)
+				return err
+			}(),
+			"CheckedMul":            func() error { _, err := gaillier.CheckedMul(bad, c, k); return err }(),
+			"Encrypt":               func() error { _, err := gaillier.Encrypt(bad, k); return err }(),
+			"EncryptWithRandomness": func() error { _, err := gaillier.EncryptWithRandomness(bad, k, big.NewInt(2)); return err }(),
+			"EncryptSigned":         func() error { _, err := gaillier.EncryptSigned(bad, big.NewInt(-2)); return err }(),
+			"ShareAdditive":         func() error { _, err := gaillier.ShareAdditive(bad, k, 2); return err }(),
+			"MulRational":           func() error { _, err := gaillier.MulRational(bad, c, big.NewInt(1), big.NewInt(2)); return err }(),
+			"ReRandomize":           func() error { _, err := gaillier.ReRandomize(bad, c); return err }(),
+			"DotProduct":            func() error { _, err := gaillier.DotProduct(bad, [][]byte{c}, [][]byte{k}); return err }(),
+		}
+		for op, err := range checks {
+			if !errors.Is(err, gaillier.ErrInvalidKey) {
+				t.Errorf("%s with %s got %v want %v", op, name, err, gaillier.ErrInvalidKey)
+			}
+		}
+	}
+
+	//private keys missing components
+	noU := &gaillier.PrivKey{KeyLen: priv.KeyLen, PubKey: priv.PubKey, L: priv.L}
+	noNPriv := &gaillier.PrivKey{KeyLen: priv.KeyLen, PubKey: *noN, L: priv.L, U: priv.U}
+	for name, bad := range map[string]*gaillier.PrivKey{"nil U": noU, "nil N": noNPriv, "nil key": nil} {
+		if _, err := gaillier.Decrypt(bad, c); !errors.Is(err, gaillier.ErrInvalidKey) {
+			t.Errorf("Decrypt with %s got %v want %v", name, err, gaillier.ErrInvalidKey)
+		}
+		if _, err := gaillier.DecryptSigned(bad, c); !errors.Is(err, gaillier.ErrInvalidKey) {
+			t.Errorf("DecryptSigned with %s got %v want %v", name, err, gaillier.ErrInvalidKey)
+		}
+	}
+	noNPriv.U = nil
+	if err := noNPriv.EnsureU(); !errors.Is(err, gaillier.ErrInvalidKey) {
+		t.Errorf("EnsureU with nil N got %v want %v", err, gaillier.ErrInvalidKey)
+	}
+
+	//non invertible elements
+	if _, err := gaillier.MulRational(pub, c, big.NewInt(1), pub.N); err != gaillier.ErrNotInvertible {
+		t.Errorf("MulRational by 1/n got %v want %v", err, gaillier.ErrNotInvertible)
+	}
+}
+
+func TestNilArguments(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	c, _ := gaillier.Encrypt(pub, big.NewInt(5).Bytes())
+	enc, _ := gaillier.EncryptEncoded(pub, big.NewInt(5), 0)
+
+	invalidKey := map[string]error{
+		"ReconstructPrivKey": second(gaillier.ReconstructPrivKey(nil, []byte{1})),
+		"AddTagged":          second(gaillier.AddTagged(nil, []byte{gaillier.TagRaw, 1}, []byte{gaillier.TagRaw, 1})),
+		"EncryptEncoded":     second(gaillier.EncryptEncoded(nil, big.NewInt(5), 0)),
+	}
+	for op, err := range invalidKey {
+		if !errors.Is(err, gaillier.ErrInvalidKey) {
+			t.Errorf("%s with a nil key got %v want %v", op, err, gaillier.ErrInvalidKey)
+		}
+	}
+
+	invalidEncoding := map[string]error{
+		"OpenTagged":      second(gaillier.OpenTagged(priv, []byte("k"), nil, nil)),
+		"Compatible a":    gaillier.Compatible(nil, enc),
+		"Compatible b":    gaillier.Compatible(enc, nil),
+		"EncryptEncoded":  second(gaillier.EncryptEncoded(pub, nil, 0)),
+		"EncryptBig":      second(gaillier.EncryptBig(pub, nil)),
+		"EncryptSigned":   second(gaillier.EncryptSigned(pub, nil)),
+		"EncodeSigned":    second(gaillier.EncodeSigned(pub, nil)),
+		"MulRational num": second(gaillier.MulRational(pub, c, nil, big.NewInt(2))),
+		"MulRational den": second(gaillier.MulRational(pub, c, big.NewInt(1), nil)),
+		"AboveThreshold":  second(priv.AboveThreshold(c, nil)),
+		"OutsourceExp":    second(gaillier.OutsourceExp(pub, nil, [][]byte{c})),
+		"DecryptEncoded":  func() error { _, _, err := gaillier.DecryptEncoded(priv, nil); return err }(),
+	}
+	for op, err := range invalidEncoding {
+		if !errors.Is(err, gaillier.ErrInvalidEncoding) {
+			t.Errorf("%s with a nil argument got %v want %v", op, err, gaillier.ErrInvalidEncoding)
+		}
+	}
+}
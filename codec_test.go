@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestCodecCiphertext(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	a, err := gaillier.EncryptAs[gaillier.Int64Codec](pub, int64(-30))
+	if err != nil {
+		t.Fatalf("Error EncryptAs int64 %v", err)
+	}
+	b, err := gaillier.EncryptAs[gaillier.Int64Codec](pub, int64(12))
+	if err != nil {
+		t.Fatalf("Error EncryptAs int64 %v", err)
+	}
+
+	//gaillier.AddCiphertext(a, raw) with raw a Ciphertext[RawCodec] doesn't compile
+	v, err := gaillier.DecryptAs(priv, gaillier.AddCiphertext(a, b))
+	if err != nil || v != int64(-18) {
+		t.Errorf("Error int64 codec sum got %v (%v) want -18", v, err)
+	}
+
+	raw, err := gaillier.EncryptAs[gaillier.RawCodec](pub, []byte{1, 0})
+	if err != nil {
+		t.Fatalf("Error EncryptAs raw %v", err)
+	}
+	sum := gaillier.AddCiphertext(raw, gaillier.CiphertextAs[gaillier.RawCodec](pub, mustEncrypt(t, pub, []byte{5})))
+	if v, _ := gaillier.DecryptAs(priv, sum); !bytes.Equal(v.([]byte), []byte{1, 5}) {
+		t.Errorf("Error raw codec sum got %v want [1 5]", v)
+	}
+
+	//Bytes is a defensive copy
+	c := sum.Bytes()
+	c[0] ^= 0xff
+	if bytes.Equal(c, sum.Bytes()) {
+		t.Errorf("Error mutating Bytes changed the ciphertext")
+	}
+
+	if _, err := gaillier.EncryptAs[gaillier.Int64Codec](pub, "42"); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+		t.Errorf("Error EncryptAs wrong value type got %v want ErrInvalidEncoding", err)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestNegateSub(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	a, _ := gaillier.Encrypt(pub, big.NewInt(50).Bytes())
+	b, _ := gaillier.Encrypt(pub, big.NewInt(80).Bytes())
+
+	if got, _ := gaillier.DecryptSigned(priv, gaillier.Negate(pub, a)); got.Int64() != -50 {
+		t.Errorf("Negate got %v want -50", got)
+	}
+	if got, _ := gaillier.DecryptSigned(priv, gaillier.Sub(pub, b, a)); got.Int64() != 30 {
+		t.Errorf("Sub got %v want 30", got)
+	}
+	if got, _ := gaillier.DecryptSigned(priv, gaillier.Sub(pub, a, b)); got.Int64() != -30 {
+		t.Errorf("Sub got %v want -30", got)
+	}
+}
+
+func TestEncryptedMax(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	rng := mrand.New(mrand.NewSource(1))
+	for i := 0; i < 40; i++ {
+		a := big.NewInt(rng.Int63n(2000) - 1000)
+		b := big.NewInt(rng.Int63n(2000) - 1000)
+		if i == 0 {
+			b.Set(a)
+		}
+
+		ca, _ := gaillier.EncryptSigned(pub, a)
+		cb, _ := gaillier.EncryptSigned(pub, b)
+
+		cmax, err := priv.EncryptedMax(ca, cb)
+		if err != nil {
+			t.Fatalf("EncryptedMax failed %v", err)
+		}
+		if bytes.Equal(cmax, ca) || bytes.Equal(cmax, cb) {
+			t.Errorf("EncryptedMax returned an input cipher unchanged")
+		}
+
+		want := a
+		if b.Cmp(a) > 0 {
+			want = b
+		}
+		if got, _ := gaillier.DecryptSigned(priv, cmax); got.Cmp(want) != 0 {
+			t.Errorf("max(%v, %v) got %v want %v", a, b, got, want)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	cThen, _ := gaillier.Encrypt(pub, []byte{10})
+	cElse, _ := gaillier.Encrypt(pub, []byte{20})
+
+	for _, tc := range []struct {
+		bit  []byte
+		want byte
+	}{{[]byte{1}, 10}, {nil, 20}} {
+		encBit, _ := gaillier.Encrypt(pub, tc.bit)
+
+		c, err := priv.Select(encBit, cThen, cElse)
+		if err != nil {
+			t.Fatalf("Select failed %v", err)
+		}
+		if bytes.Equal(c, cThen) || bytes.Equal(c, cElse) {
+			t.Errorf("Error Select returned an input cipher unchanged")
+		}
+		if d, _ := gaillier.Decrypt(priv, c); !bytes.Equal(d, []byte{tc.want}) {
+			t.Errorf("Error Select with bit %v got %v want %v", tc.bit, d, tc.want)
+		}
+	}
+
+	two, _ := gaillier.Encrypt(pub, []byte{2})
+	if _, err := priv.Select(two, cThen, cElse); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+		t.Errorf("Error Select with selector 2 got %v want ErrInvalidEncoding", err)
+	}
+}
+
+func TestAboveThreshold(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	cases := []struct {
+		value, threshold int64
+		want             bool
+	}{
+		{101, 100, true},
+		{100, 100, false},
+		{99, 100, false},
+		{-5, -10, true},
+		{-10, -10, false},
+		{-11, -10, false},
+		{-1, 0, false},
+		{1, -1, true},
+	}
+
+	for _, tc := range cases {
+		c, _ := gaillier.EncryptInt64(pub, tc.value)
+		got, err := priv.AboveThreshold(c, big.NewInt(tc.threshold))
+		if err != nil {
+			t.Fatalf("AboveThreshold failed %v", err)
+		}
+		if got != tc.want {
+			t.Errorf("Error AboveThreshold(%v, %v) got %v want %v", tc.value, tc.threshold, got, tc.want)
+		}
+	}
+}
+
+func TestConstantSub(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	c, _ := gaillier.Encrypt(pub, big.NewInt(30).Bytes())
+
+	cases := []struct {
+		k, kMinusM, mMinusK int64
+	}{
+		{100, 70, -70},
+		{30, 0, 0},
+		{10, -20, 20},
+	}
+	for _, tc := range cases {
+		k := big.NewInt(tc.k).Bytes()
+		if got, _ := gaillier.DecryptSigned(priv, gaillier.ConstantSub(pub, k, c)); got.Int64() != tc.kMinusM {
+			t.Errorf("Error ConstantSub %v - 30 got %v want %v", tc.k, got, tc.kMinusM)
+		}
+		if got, _ := gaillier.DecryptSigned(priv, gaillier.SubConstant(pub, c, k)); got.Int64() != tc.mMinusK {
+			t.Errorf("Error SubConstant 30 - %v got %v want %v", tc.k, got, tc.mMinusK)
+		}
+	}
+
+	// unsigned, k < m wraps around n
+	d, _ := gaillier.Decrypt(priv, gaillier.ConstantSub(pub, []byte{10}, c))
+	if want := new(big.Int).Sub(pub.N, big.NewInt(20)); new(big.Int).SetBytes(d).Cmp(want) != 0 {
+		t.Errorf("Error ConstantSub 10 - 30 got %v want n - 20", new(big.Int).SetBytes(d))
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestEncryptedCounter(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	counter, err := gaillier.NewEncryptedCounter(pub)
+	if err != nil {
+		t.Fatalf("NewEncryptedCounter failed %v", err)
+	}
+	if v, _ := priv.ReadCounter(counter); v != 0 {
+		t.Errorf("Error new counter got %v want 0", v)
+	}
+
+	const workers, perWorker = 8, 50
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				counter.Inc()
+			}
+			counter.Add(10)
+			counter.Add(-5)
+		}()
+	}
+	wg.Wait()
+
+	if v, err := priv.ReadCounter(counter); err != nil || v != workers*(perWorker+5) {
+		t.Errorf("Error counter got %v want %v (%v)", v, workers*(perWorker+5), err)
+	}
+
+	counter.Add(-1000)
+	if v, _ := priv.ReadCounter(counter); v != workers*(perWorker+5)-1000 {
+		t.Errorf("Error negative counter got %v want %v", v, workers*(perWorker+5)-1000)
+	}
+}
+
+func TestEncryptedCounterHidesIncrements(t *testing.T) {
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	counter, err := gaillier.NewEncryptedCounter(pub)
+	if err != nil {
+		t.Fatalf("NewEncryptedCounter failed %v", err)
+	}
+
+	//c_after / c_before mod n^2 for an update
+	ratio := func(update func()) *big.Int {
+		before := new(big.Int).SetBytes(counter.Cipher())
+		update()
+		after := new(big.Int).SetBytes(counter.Cipher())
+		inv := new(big.Int).ModInverse(before, pub.Nsq)
+		return inv.Mod(inv.Mul(inv, after), pub.Nsq)
+	}
+
+	first, second := ratio(func() { counter.Add(7) }), ratio(func() { counter.Add(7) })
+	if first.Cmp(second) == 0 {
+		t.Errorf("Error two updates by the same k gave the same ratio")
+	}
+	//without re-randomization the ratio would be g^k = 1 + k*n
+	gk := new(big.Int).Add(new(big.Int).Mul(big.NewInt(7), pub.N), big.NewInt(1))
+	if first.Cmp(gk) == 0 || second.Cmp(gk) == 0 {
+		t.Errorf("Error update ratio is g^k, the increment is visible")
+	}
+}
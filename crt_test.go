@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestDecryptCRTMatchesDecrypt(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	//edges of the plaintext range, around n/2 and random values
+	half := new(big.Int).Rsh(pub.N, 1)
+	messages := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(2)}
+	for _, base := range []*big.Int{half, pub.N} {
+		for d := int64(-3); d <= 3; d++ {
+			m := new(big.Int).Add(base, big.NewInt(d))
+			if m.Sign() >= 0 && m.Cmp(pub.N) < 0 {
+				messages = append(messages, m)
+			}
+		}
+	}
+	for i := 0; i < 200; i++ {
+		m, _ := rand.Int(rand.Reader, pub.N)
+		messages = append(messages, m)
+	}
+
+	for name, k := range map[string]*gaillier.PrivKey{"standard g": priv, "general g": crtGeneralGKey(t, priv)} {
+		for _, m := range messages {
+			c, err := gaillier.EncryptWithReader(rand.Reader, &k.PubKey, m.Bytes())
+			if err != nil {
+				t.Fatalf("Error %s encrypting %v", name, err)
+			}
+			want, err := gaillier.Decrypt(k, c)
+			if err != nil {
+				t.Fatalf("Error %s Decrypt %v", name, err)
+			}
+			got, err := gaillier.DecryptCRT(k, c)
+			if err != nil {
+				t.Fatalf("Error %s DecryptCRT %v", name, err)
+			}
+			if !bytes.Equal(got, want) || new(big.Int).SetBytes(got).Cmp(m) != 0 {
+				t.Errorf("Error %s DecryptCRT got %x want %x", name, got, m)
+			}
+			par, err := gaillier.DecryptCRT(k, c, gaillier.WithParallelCRT(true))
+			if err != nil || !bytes.Equal(par, got) {
+				t.Errorf("Error %s parallel DecryptCRT got %x (%v) want %x", name, par, err, got)
+			}
+		}
+	}
+}
+
+// crtGeneralGKey is generalGKey keeping the factors of n
+func crtGeneralGKey(t *testing.T, priv *gaillier.PrivKey) *gaillier.PrivKey {
+	k := generalGKey(t, priv)
+	k.P, k.Q = priv.P, priv.Q
+	return k
+}
+
+func TestDecryptCRTMissingFactors(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	c := mustEncrypt(t, pub, []byte{42})
+
+	noFactors := &gaillier.PrivKey{KeyLen: priv.KeyLen, PubKey: priv.PubKey, L: priv.L, U: priv.U}
+	wrong := &gaillier.PrivKey{KeyLen: priv.KeyLen, PubKey: priv.PubKey, L: priv.L, U: priv.U, P: priv.P, Q: priv.P}
+	for name, k := range map[string]*gaillier.PrivKey{"no factors": noFactors, "wrong factors": wrong} {
+		if _, err := gaillier.DecryptCRT(k, c); !errors.Is(err, gaillier.ErrInvalidKey) {
+			t.Errorf("Error DecryptCRT %s got %v want ErrInvalidKey", name, err)
+		}
+	}
+}
+
+var (
+	crtBenchOnce sync.Once
+	crtBenchKey  *gaillier.PrivKey
+)
+
+var (
+	crtSizesMu   sync.Mutex
+	crtSizesKeys = map[int]*gaillier.PrivKey{}
+)
+
+// crtSizeKey returns a cached key of bits bits, shared by the serial and parallel benchmarks
+func crtSizeKey(b *testing.B, bits int) *gaillier.PrivKey {
+	crtSizesMu.Lock()
+	defer crtSizesMu.Unlock()
+	if k, ok := crtSizesKeys[bits]; ok {
+		return k
+	}
+	_, k, err := gaillier.GenerateKeyPair(rand.Reader, bits)
+	if err != nil {
+		b.Fatal(err)
+	}
+	crtSizesKeys[bits] = k
+	return k
+}
+
+// compare the serial and parallel ns/op per size, the parallel path needs GOMAXPROCS >= 2 to win
+func benchmarkDecryptCRTParallel(b *testing.B, parallel bool) {
+	for _, bits := range []int{2048, 3072, 4096} {
+		b.Run(fmt.Sprint(bits), func(b *testing.B) {
+			k := crtSizeKey(b, bits)
+			m, _ := rand.Int(rand.Reader, k.N)
+			c, err := gaillier.Encrypt(k, m.Bytes())
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := gaillier.DecryptCRT(k, c, gaillier.WithParallelCRT(parallel)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDecryptCRTSerial(b *testing.B) { benchmarkDecryptCRTParallel(b, false) }
+
+func BenchmarkDecryptCRTParallel(b *testing.B) { benchmarkDecryptCRTParallel(b, true) }
+
+func benchmarkDecrypt(b *testing.B, decrypt func(*gaillier.PrivKey, []byte) ([]byte, error)) {
+	crtBenchOnce.Do(func() {
+		_, crtBenchKey, _ = gaillier.GenerateKeyPair(rand.Reader, 2048)
+	})
+	m, _ := rand.Int(rand.Reader, crtBenchKey.N)
+	c, err := gaillier.Encrypt(&crtBenchKey.PubKey, m.Bytes())
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := decrypt(crtBenchKey, c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecryptCRT(b *testing.B) {
+	benchmarkDecrypt(b, func(k *gaillier.PrivKey, c []byte) ([]byte, error) { return gaillier.DecryptCRT(k, c) })
+}
+
+func BenchmarkDecryptNonCRT(b *testing.B) { benchmarkDecrypt(b, gaillier.Decrypt) }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestDecryptTyped(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	for _, v := range []int64{0, 1, -1, 42, -42, math.MaxInt64, math.MinInt64} {
+		c, err := gaillier.EncryptInt64(pub, v)
+		if err != nil {
+			t.Fatalf("EncryptInt64 failed %v", err)
+		}
+
+		got, err := gaillier.DecryptInt64(priv, c)
+		if err != nil || got != v {
+			t.Errorf("Error DecryptInt64 got %v want %v (%v)", got, v, err)
+		}
+
+		// bytes & big.Int see the raw residue, v mod n
+		want := new(big.Int).Mod(big.NewInt(v), pub.N)
+		m, err := gaillier.DecryptBigInt(priv, c)
+		if err != nil || m.Cmp(want) != 0 {
+			t.Errorf("Error DecryptBigInt got %v want %v (%v)", m, want, err)
+		}
+		b, err := gaillier.DecryptBytes(priv, c)
+		if err != nil || new(big.Int).SetBytes(b).Cmp(want) != 0 {
+			t.Errorf("Error DecryptBytes got %v want %v (%v)", b, want, err)
+		}
+	}
+
+	zero, _ := gaillier.Encrypt(pub, nil)
+	if b, _ := gaillier.DecryptBytes(priv, zero); len(b) != 0 {
+		t.Errorf("Error DecryptBytes of zero got %v want empty", b)
+	}
+
+	over := new(big.Int).Lsh(big.NewInt(1), 64)
+	c, _ := gaillier.Encrypt(pub, over.Bytes())
+	if _, err := gaillier.DecryptInt64(priv, c); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+		t.Errorf("Error DecryptInt64 overflow got %v want ErrInvalidEncoding", err)
+	}
+	if m, _ := gaillier.DecryptBigInt(priv, c); m.Cmp(over) != 0 {
+		t.Errorf("Error DecryptBigInt got %v want %v", m, over)
+	}
+}
+
+func TestDecryptUint64(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	for _, v := range []uint64{0, 1, 1 << 63, math.MaxUint64} {
+		c, err := gaillier.EncryptUint64(pub, v)
+		if err != nil {
+			t.Fatalf("EncryptUint64 failed %v", err)
+		}
+		if got, err := gaillier.DecryptUint64(priv, c); err != nil || got != v {
+			t.Errorf("Error DecryptUint64 got %v want %v (%v)", got, v, err)
+		}
+	}
+
+	// max uint64 + 1 is still below n but doesn't fit a uint64
+	c, _ := gaillier.EncryptUint64(pub, math.MaxUint64)
+	c = gaillier.AddConstant(pub, c, []byte{1})
+	if _, err := gaillier.DecryptUint64(priv, c); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+		t.Errorf("Error DecryptUint64 overflow got %v want ErrInvalidEncoding", err)
+	}
+
+	// with a key smaller than 64 bits max uint64 doesn't fit n
+	small, _, err := gaillier.GenerateKeyPair(rand.Reader, 32)
+	if err != nil {
+		t.Fatalf("Error Generating Keypair %v", err)
+	}
+	if _, err := gaillier.EncryptUint64(small, math.MaxUint64); !errors.Is(err, gaillier.ErrLongMessage) {
+		t.Errorf("Error EncryptUint64 above n got %v want ErrLongMessage", err)
+	}
+}
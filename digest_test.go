@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestCanonicalDigest(t *testing.T) {
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	ciphers := encryptRange(t, pub, 8)
+	want := gaillier.CanonicalDigest(ciphers)
+
+	shuffled := append([][]byte(nil), ciphers...)
+	rng := mrand.New(mrand.NewSource(3))
+	for i := 0; i < 5; i++ {
+		rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		if got := gaillier.CanonicalDigest(shuffled); !bytes.Equal(got, want) {
+			t.Errorf("Error CanonicalDigest depends on the order")
+		}
+	}
+
+	changed := append([][]byte(nil), ciphers...)
+	changed[3] = mustEncrypt(t, pub, []byte{3})
+	if bytes.Equal(gaillier.CanonicalDigest(changed), want) {
+		t.Errorf("Error CanonicalDigest unchanged after replacing a cipher")
+	}
+
+	// multiset : a repeated cipher is not the same as a single one
+	if bytes.Equal(gaillier.CanonicalDigest(append(ciphers, ciphers[0])), want) {
+		t.Errorf("Error CanonicalDigest ignores duplicates")
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestEncodedAdd(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	other, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	//1.25 + 2.50 with two decimals
+	a, err1 := gaillier.EncryptEncoded(pub, big.NewInt(125), -2)
+	b, err2 := gaillier.EncryptEncoded(pub, big.NewInt(250), -2)
+	if err1 != nil || err2 != nil {
+		t.Fatalf("Failed to encrypt encoded values %v %v", err1, err2)
+	}
+	if err := gaillier.Compatible(a, b); err != nil {
+		t.Errorf("Same key & exponent reported incompatible %v", err)
+	}
+
+	sum, err := gaillier.AddEncoded(pub, a, b)
+	if err != nil {
+		t.Fatalf("AddEncoded failed %v", err)
+	}
+	m, exp, err := gaillier.DecryptEncoded(priv, sum)
+	if err != nil || m.Cmp(big.NewInt(375)) != 0 || exp != -2 {
+		t.Errorf("Error adding encoded values got %v * 10^%d want 375 * 10^-2 (%v)", m, exp, err)
+	}
+
+	//mismatched exponent
+	c, _ := gaillier.EncryptEncoded(pub, big.NewInt(25), -1)
+	if err := gaillier.Compatible(a, c); !errors.Is(err, gaillier.ErrIncompatible) {
+		t.Errorf("Mismatched exponent got %v want %v", err, gaillier.ErrIncompatible)
+	}
+	if _, err := gaillier.AddEncoded(pub, a, c); !errors.Is(err, gaillier.ErrIncompatible) {
+		t.Errorf("Adding mismatched exponents got %v want %v", err, gaillier.ErrIncompatible)
+	}
+
+	//mismatched key
+	d, _ := gaillier.EncryptEncoded(other, big.NewInt(125), -2)
+	if err := gaillier.Compatible(a, d); !errors.Is(err, gaillier.ErrIncompatible) {
+		t.Errorf("Mismatched key got %v want %v", err, gaillier.ErrIncompatible)
+	}
+	if _, err := gaillier.AddEncoded(pub, a, d); !errors.Is(err, gaillier.ErrIncompatible) {
+		t.Errorf("Adding mismatched keys got %v want %v", err, gaillier.ErrIncompatible)
+	}
+	if _, _, err := gaillier.DecryptEncoded(priv, d); !errors.Is(err, gaillier.ErrIncompatible) {
+		t.Errorf("Decrypting under the wrong key got %v want %v", err, gaillier.ErrIncompatible)
+	}
+}
+
+func TestWrapCiphertext(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	other, otherPriv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	c, err := gaillier.Encrypt(pub, big.NewInt(125).Bytes())
+	if err != nil {
+		t.Fatalf("Encrypt failed %v", err)
+	}
+	a, err := gaillier.WrapCiphertext(pub, pub.Fingerprint(), c, -2)
+	if err != nil {
+		t.Fatalf("WrapCiphertext failed %v", err)
+	}
+	if a.KeyFingerprint != pub.Fingerprint() {
+		t.Errorf("Error wrapped fingerprint got %v want %v", a.KeyFingerprint, pub.Fingerprint())
+	}
+	m, exp, err := gaillier.DecryptEncoded(priv, a)
+	if err != nil || m.Cmp(big.NewInt(125)) != 0 || exp != -2 {
+		t.Errorf("Error decrypting wrapped cipher got %v * 10^%d want 125 * 10^-2 (%v)", m, exp, err)
+	}
+
+	//a raw cipher recorded under another key
+	if err := gaillier.CheckFingerprint(other, pub.Fingerprint()); !errors.Is(err, gaillier.ErrIncompatible) {
+		t.Errorf("Mismatched raw fingerprint got %v want %v", err, gaillier.ErrIncompatible)
+	}
+	if _, err := gaillier.WrapCiphertext(other, pub.Fingerprint(), c, -2); !errors.Is(err, gaillier.ErrIncompatible) {
+		t.Errorf("Wrapping under the wrong key got %v want %v", err, gaillier.ErrIncompatible)
+	}
+	if _, _, err := gaillier.DecryptEncoded(otherPriv, a); !errors.Is(err, gaillier.ErrIncompatible) {
+		t.Errorf("Decrypting wrapped cipher under the wrong key got %v want %v", err, gaillier.ErrIncompatible)
+	}
+	if _, err := gaillier.WrapCiphertext(pub, pub.Fingerprint(), nil, 0); !errors.Is(err, gaillier.ErrInvalidCiphertext) {
+		t.Errorf("Wrapping an empty cipher got %v want %v", err, gaillier.ErrInvalidCiphertext)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	other, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	if pub.Fingerprint() != priv.Fingerprint() {
+		t.Errorf("Public & private key fingerprints differ")
+	}
+	if pub.Fingerprint() == other.Fingerprint() {
+		t.Errorf("Distinct keys share a fingerprint")
+	}
+	if len(pub.Fingerprint()) != 64 {
+		t.Errorf("Fingerprint %q is not a hex SHA-256", pub.Fingerprint())
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestCiphertextHex(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	m := big.NewInt(2024)
+	c, err := gaillier.Encrypt(pub, m.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to encrypt %v", err)
+	}
+
+	//leading zero bytes of fixed-width ciphers must survive
+	for _, cipher := range [][]byte{c, append([]byte{0, 0}, c...)} {
+		back, err := gaillier.CiphertextFromHex(gaillier.CiphertextToHex(cipher))
+		if err != nil {
+			t.Fatalf("Failed to decode hex %v", err)
+		}
+		if !bytes.Equal(back, cipher) {
+			t.Errorf("Hex round trip changed the cipher")
+		}
+	}
+
+	back, _ := gaillier.CiphertextFromHex(gaillier.CiphertextToHex(c))
+	d, err := gaillier.Decrypt(priv, back)
+	if err != nil || new(big.Int).SetBytes(d).Cmp(m) != 0 {
+		t.Errorf("Error decrypting hex round tripped cipher got %v want %v", new(big.Int).SetBytes(d), m)
+	}
+
+	for _, bad := range []string{"abc", "zz", "0x12"} {
+		if _, err := gaillier.CiphertextFromHex(bad); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+			t.Errorf("CiphertextFromHex(%q) got %v want %v", bad, err, gaillier.ErrInvalidEncoding)
+		}
+	}
+}
+
+func TestEncryptDecryptString(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	pubB64, err1 := gaillier.PubKeyToBase64(pub)
+	privB64, err2 := gaillier.PrivKeyToBase64(priv)
+	if err1 != nil || err2 != nil {
+		t.Fatalf("Failed to encode keys %v %v", err1, err2)
+	}
+
+	msg := base64.StdEncoding.EncodeToString(big.NewInt(4242).Bytes())
+	c, err := gaillier.EncryptString(pubB64, msg)
+	if err != nil {
+		t.Fatalf("EncryptString failed %v", err)
+	}
+	d, err := gaillier.DecryptString(privB64, c)
+	if err != nil {
+		t.Fatalf("DecryptString failed %v", err)
+	}
+	if d != msg {
+		t.Errorf("String round trip got %q want %q", d, msg)
+	}
+
+	//malformed inputs
+	notKey := base64.StdEncoding.EncodeToString([]byte("not a key"))
+	cases := []struct {
+		name string
+		err  error
+	}{
+		{"bad key base64", func() error { _, err := gaillier.EncryptString("%%%", msg); return err }()},
+		{"key not gob", func() error { _, err := gaillier.EncryptString(notKey, msg); return err }()},
+		{"bad message base64", func() error { _, err := gaillier.EncryptString(pubB64, "@@@"); return err }()},
+		{"bad priv key base64", func() error { _, err := gaillier.DecryptString("%%%", c); return err }()},
+		{"bad cipher base64", func() error { _, err := gaillier.DecryptString(privB64, "a"); return err }()},
+	}
+	for _, c := range cases {
+		if !errors.Is(c.err, gaillier.ErrInvalidEncoding) {
+			t.Errorf("%s got %v want %v", c.name, c.err, gaillier.ErrInvalidEncoding)
+		}
+	}
+}
+
+func TestPackCiphertext(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	size := pub.CiphertextSize()
+
+	for i := int64(0); i < 32; i++ {
+		c, _ := gaillier.Encrypt(pub, big.NewInt(i).Bytes())
+
+		//fixed-width input with padding
+		padded := append(make([]byte, size-len(c)), c...)
+		packed, err := gaillier.PackCiphertext(pub, padded)
+		if err != nil {
+			t.Fatalf("PackCiphertext failed %v", err)
+		}
+		if len(packed) > size {
+			t.Errorf("Packed cipher is %d bytes, CiphertextSize is %d", len(packed), size)
+		}
+		if len(packed) > 0 && packed[0] == 0 {
+			t.Errorf("Packed cipher kept a leading zero byte")
+		}
+
+		unpacked, err := gaillier.UnpackCiphertext(pub, packed)
+		if err != nil {
+			t.Fatalf("UnpackCiphertext failed %v", err)
+		}
+		if !bytes.Equal(unpacked, padded) || len(unpacked) != size {
+			t.Errorf("Pack round trip changed the cipher")
+		}
+
+		d, err := gaillier.Decrypt(priv, unpacked)
+		if err != nil || new(big.Int).SetBytes(d).Int64() != i {
+			t.Errorf("Error decrypting unpacked cipher got %v want %d", new(big.Int).SetBytes(d), i)
+		}
+	}
+
+	//an out of range cipher is rejected, not silently reduced
+	c, _ := gaillier.Encrypt(pub, big.NewInt(9).Bytes())
+	unreduced := new(big.Int).Add(new(big.Int).SetBytes(c), pub.Nsq)
+	for name, bad := range map[string][]byte{"n^2": pub.Nsq.Bytes(), "cipher + n^2": unreduced.Bytes()} {
+		if _, err := gaillier.PackCiphertext(pub, bad); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+			t.Errorf("Packing %s got %v want %v", name, err, gaillier.ErrInvalidEncoding)
+		}
+	}
+
+	if _, err := gaillier.UnpackCiphertext(pub, pub.Nsq.Bytes()); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+		t.Errorf("Unpacking n^2 got %v want %v", err, gaillier.ErrInvalidEncoding)
+	}
+}
+
+func TestCloneCiphertext(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	stored := map[string][]byte{"total": mustEncrypt(t, pub, []byte{42})}
+	retained := stored["total"]
+	snapshot := append([]byte(nil), retained...)
+
+	//a caller mutating and appending to its copy leaves the stored cipher intact
+	c := gaillier.CloneCiphertext(stored["total"])
+	c[0] ^= 0xff
+	c = append(c[:len(c)-1], 0, 0)
+	if !bytes.Equal(retained, snapshot) || !bytes.Equal(stored["total"], snapshot) {
+		t.Errorf("Error mutating the clone changed the stored cipher")
+	}
+	if d, _ := gaillier.Decrypt(priv, stored["total"]); !bytes.Equal(d, []byte{42}) {
+		t.Errorf("Error stored cipher decrypts to %v want 42", d)
+	}
+
+	if gaillier.CloneCiphertext(nil) != nil {
+		t.Errorf("Error CloneCiphertext(nil) is not nil")
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestErrorCodes(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	c := mustEncrypt(t, pub, []byte{1})
+	tooLong := new(big.Int).Add(pub.N, big.NewInt(1)).Bytes()
+
+	cases := []struct {
+		name string
+		err  error
+		want gaillier.ErrorCode
+	}{
+		{"encrypt too long", second(gaillier.Encrypt(pub, tooLong)), gaillier.ErrCodeMessageTooLong},
+		{"decrypt too long", second(gaillier.Decrypt(priv, pub.Nsq.Bytes())), gaillier.ErrCodeInvalidCiphertext},
+		{"encrypt safe zero", second(gaillier.EncryptSafe(pub, nil)), gaillier.ErrCodeEmptyMessage},
+		{"bad randomness", second(gaillier.EncryptWithRandomness(pub, []byte{1}, big.NewInt(0))), gaillier.ErrCodeInvalidRandomness},
+		{"bad hex", second(gaillier.CiphertextFromHex("zz")), gaillier.ErrCodeInvalidEncoding},
+		{"nil key", second(gaillier.Encrypt(&gaillier.PubKey{}, []byte{1})), gaillier.ErrCodeInvalidKey},
+		{"not invertible", second(gaillier.MulRational(pub, c, big.NewInt(1), big.NewInt(0))), gaillier.ErrCodeNotInvertible},
+		{"share count", second(gaillier.ShareAdditive(pub, []byte{1}, 0)), gaillier.ErrCodeInvalidShareCount},
+		{"dimensions", second(gaillier.DotProduct(pub, [][]byte{c}, nil)), gaillier.ErrCodeDimensionMismatch},
+		{"transcript", second(gaillier.ReplayTranscript(pub, [][]byte{c}, []gaillier.Op{{Kind: gaillier.OpAdd, A: 0, B: 5}})), gaillier.ErrCodeInvalidTranscript},
+		{"tag mismatch", second(gaillier.AddTagged(pub, append([]byte{gaillier.TagRaw}, c...), append([]byte{gaillier.TagInt64}, c...))), gaillier.ErrCodeIncompatible},
+	}
+
+	for _, tc := range cases {
+		if got := gaillier.CodeOf(tc.err); got != tc.want {
+			t.Errorf("Error %s code got %v want %v (%v)", tc.name, got, tc.want, tc.err)
+		}
+		if !errors.Is(tc.err, &gaillier.GaillierError{Code: tc.want}) {
+			t.Errorf("Error %s doesn't match code %v with errors.Is", tc.name, tc.want)
+		}
+	}
+
+	// the historical values keep working, wrapped or not
+	if !errors.Is(cases[0].err, gaillier.ErrLongMessage) || !errors.Is(fmt.Errorf("ctx: %w", gaillier.ErrLongMessage), gaillier.ErrLongMessage) {
+		t.Errorf("Error errors.Is(err, ErrLongMessage) broke")
+	}
+	if errors.Is(gaillier.ErrLongMessage, gaillier.ErrInvalidKey) {
+		t.Errorf("Error distinct codes match each other")
+	}
+	// a typed nil target matches nothing, and doesn't panic
+	if errors.Is(cases[0].err, (*gaillier.GaillierError)(nil)) || errors.Is(gaillier.ErrInvalidKey, (*gaillier.GaillierError)(nil)) {
+		t.Errorf("Error errors.Is matched a nil *GaillierError")
+	}
+	// every decryption path rejects a cipher >= n^2 as an invalid ciphertext
+	d, _ := gaillier.NewDecryptDelegation(priv)
+	invalid := map[string]error{
+		"decrypt":       second(gaillier.Decrypt(priv, pub.Nsq.Bytes())),
+		"decrypt crt":   second(gaillier.DecryptCRT(priv, pub.Nsq.Bytes())),
+		"decrypt scrub": second(gaillier.DecryptAndScrub(priv, pub.Nsq.Bytes())),
+		"outsource":     second(d.OutsourceDecryptSetup(pub.Nsq.Bytes())),
+	}
+	for name, err := range invalid {
+		if !errors.Is(err, gaillier.ErrInvalidCiphertext) {
+			t.Errorf("Error %s of a cipher >= n^2 got %v want ErrInvalidCiphertext", name, err)
+		}
+		//it was ErrLongMessage before ErrInvalidCiphertext existed
+		if !errors.Is(err, gaillier.ErrLongMessage) {
+			t.Errorf("Error %s of a cipher >= n^2 no longer matches ErrLongMessage", name)
+		}
+	}
+	if errors.Is(gaillier.ErrLongMessage, gaillier.ErrInvalidCiphertext) {
+		t.Errorf("Error ErrLongMessage matches ErrInvalidCiphertext")
+	}
+
+	if got := gaillier.CodeOf(errors.New("other")); got != gaillier.ErrCodeUnknown {
+		t.Errorf("Error CodeOf foreign error got %v want ErrCodeUnknown", got)
+	}
+	if want := "Gaillier Error #1: "; gaillier.ErrLongMessage.Error()[:len(want)] != want {
+		t.Errorf("Error message format changed %q", gaillier.ErrLongMessage.Error())
+	}
+}
+
+// second returns the error of a two value call
+func second[T any](_ T, err error) error {
+	return err
+}
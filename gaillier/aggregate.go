@@ -0,0 +1,155 @@
+package gaillier
+
+import (
+	"fmt"
+	"math/big"
+	"runtime"
+	"sync"
+)
+
+/*
+	Parallel aggregation
+
+	Sums of ciphers are products mod n^2, which are associative and commutative,
+	so splitting them across goroutines can't change the result. On top of that
+	the inputs are cut in fixed contiguous chunks and the partial products are
+	combined in chunk order, so the exact same multiplications happen whatever
+	the scheduling. Nothing here re-randomizes : the output only depends on the inputs.
+*/
+
+const (
+	//sumChunk is the smallest slice of ciphers worth a goroutine when only multiplying
+	sumChunk = 256
+	//dotChunk is the smallest slice of terms worth a goroutine when each term is an Exp
+	dotChunk = 1
+)
+
+// DotProduct returns an encryption of sum(m_i * w_i) for ciphers of m_i and plaintext weights w_i
+// it returns ErrDimensionMismatch when the slices have different lengths
+func DotProduct(pubkey *PubKey, ciphers, weights [][]byte) ([]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if len(ciphers) != len(weights) {
+		return nil, ErrDimensionMismatch
+	}
+
+	return productMod(pubkey.nsq(), len(ciphers), dotChunk, func(i int) *big.Int {
+		c := new(big.Int).SetBytes(ciphers[i])
+		return modExp(c, new(big.Int).SetBytes(weights[i]), pubkey.nsq())
+	}).Bytes(), nil
+}
+
+// MatVec returns encryptions of A.x + b : row i is an encryption of sum_j(A[i][j] * x_j) + b[i]
+// for ciphers x_j and plaintext A & b, entries may be negative in the signed
+// convention (see EncodeSigned) and results are then read back with DecryptSigned
+// mismatched sizes return an error wrapping ErrDimensionMismatch
+func MatVec(pubkey *PubKey, A [][][]byte, x [][]byte, b [][]byte) ([][]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if len(A) != len(b) {
+		return nil, fmt.Errorf("%w: %d rows for a bias of %d", ErrDimensionMismatch, len(A), len(b))
+	}
+	for i, row := range A {
+		if len(row) != len(x) {
+			return nil, fmt.Errorf("%w: row %d has %d columns for a vector of %d", ErrDimensionMismatch, i, len(row), len(x))
+		}
+	}
+
+	out := make([][]byte, len(A))
+	for i, row := range A {
+		dot, err := DotProduct(pubkey, x, row)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = AddConstant(pubkey, dot, b[i])
+	}
+
+	return out, nil
+}
+
+// PrefixSum returns [Enc(a0), Enc(a0+a1), ...] for ciphers [Enc(a0), Enc(a1), ...]
+// with one multiplication per cipher, an empty input returns an error wrapping ErrDimensionMismatch
+// every prefix wraps mod n like Add does : the largest prefix must stay below n (or
+// within +-(n-1)/2 for signed values) to decrypt to the true sum
+// consecutive outputs share factors, out[i] / out[i-1] is ciphers[i] itself,
+// use PrefixSumReRandomized when the outputs leave together
+func PrefixSum(pubkey *PubKey, ciphers [][]byte) ([][]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if len(ciphers) == 0 {
+		return nil, fmt.Errorf("%w: no ciphers", ErrDimensionMismatch)
+	}
+
+	out := make([][]byte, len(ciphers))
+	acc := big.NewInt(1)
+	c := new(big.Int)
+	for i, cipher := range ciphers {
+		acc.Mod(acc.Mul(acc, c.SetBytes(cipher)), pubkey.nsq())
+		out[i] = acc.Bytes()
+	}
+
+	return out, nil
+}
+
+// PrefixSumReRandomized is PrefixSum re-randomizing every prefix, so they can't be related to
+// each other or to the inputs
+func PrefixSumReRandomized(pubkey *PubKey, ciphers [][]byte) ([][]byte, error) {
+
+	out, err := PrefixSum(pubkey, ciphers)
+	if err != nil {
+		return nil, err
+	}
+
+	return ReRandomizeBatch(pubkey, out)
+}
+
+// productMod returns prod term(i) mod m for i in [0, n)
+// with at least minChunk terms per goroutine and one goroutine per CPU at most
+func productMod(m *big.Int, n, minChunk int, term func(i int) *big.Int) *big.Int {
+
+	workers := runtime.GOMAXPROCS(0)
+	if limit := (n + minChunk - 1) / minChunk; workers > limit {
+		workers = limit
+	}
+	if workers <= 1 {
+		return productRange(m, 0, n, term)
+	}
+
+	chunk := (n + workers - 1) / workers
+	partials := make([]*big.Int, workers)
+
+	var wg sync.WaitGroup
+	for w := range partials {
+		lo, hi := w*chunk, (w+1)*chunk
+		if hi > n {
+			hi = n
+		}
+		wg.Add(1)
+		go func(w, lo, hi int) {
+			defer wg.Done()
+			partials[w] = productRange(m, lo, hi, term)
+		}(w, lo, hi)
+	}
+	wg.Wait()
+
+	res := big.NewInt(1)
+	for _, p := range partials {
+		res.Mod(res.Mul(res, p), m)
+	}
+
+	return res
+}
+
+func productRange(m *big.Int, lo, hi int, term func(i int) *big.Int) *big.Int {
+	res := big.NewInt(1)
+	for i := lo; i < hi; i++ {
+		res.Mod(res.Mul(res, term(i)), m)
+	}
+	return res
+}
@@ -0,0 +1,125 @@
+package gaillier
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"slices"
+)
+
+/*
+	Session archives
+
+	An archive bundles a private key and named ciphers :
+	magic "GMAR", version byte, then frames as written by CiphertextWriter :
+	the gob encoded private key, the entry count (4 bytes big-endian) and for
+	each entry, sorted by name, its name and its cipher.
+	The private key is stored in clear, encrypt the archive as a whole or keep
+	the key apart with MarshalEncryptedPrivKey when it leaves a trusted place.
+*/
+
+const archiveVersion = 1
+
+var archiveMagic = []byte("GMAR")
+
+// WriteArchive writes priv and the named ciphers to w
+func WriteArchive(w io.Writer, priv *PrivKey, named map[string][]byte) error {
+
+	if err := priv.Validate(); err != nil {
+		return err
+	}
+
+	key := new(bytes.Buffer)
+	if err := gob.NewEncoder(key).Encode(priv); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(append(slices.Clone(archiveMagic), archiveVersion)); err != nil {
+		return err
+	}
+	cw := NewCiphertextWriter(w)
+	if err := cw.Write(key.Bytes()); err != nil {
+		return err
+	}
+	if err := cw.Write(binary.BigEndian.AppendUint32(nil, uint32(len(named)))); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(named))
+	for name := range named {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if err := cw.Write([]byte(name)); err != nil {
+			return err
+		}
+		if err := cw.Write(named[name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadArchive reads an archive written by WriteArchive
+// malformed or truncated archives return an error wrapping ErrInvalidEncoding
+func ReadArchive(r io.Reader) (*PrivKey, map[string][]byte, error) {
+
+	header := make([]byte, len(archiveMagic)+1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, nil, fmt.Errorf("%w: archive header: %w", ErrInvalidEncoding, err)
+	}
+	if !bytes.Equal(header[:len(archiveMagic)], archiveMagic) {
+		return nil, nil, fmt.Errorf("%w: not an archive", ErrInvalidEncoding)
+	}
+	if v := header[len(archiveMagic)]; v != archiveVersion {
+		return nil, nil, fmt.Errorf("%w: archive version %d", ErrInvalidEncoding, v)
+	}
+
+	cr := NewCiphertextReader(r)
+	next := func() ([]byte, error) {
+		frame, err := cr.Read()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: truncated archive: %w", ErrInvalidEncoding, io.ErrUnexpectedEOF)
+		}
+		return frame, err
+	}
+
+	key, err := next()
+	if err != nil {
+		return nil, nil, err
+	}
+	priv := new(PrivKey)
+	if err := gob.NewDecoder(bytes.NewReader(key)).Decode(priv); err != nil {
+		return nil, nil, fmt.Errorf("%w: archive key: %v", ErrInvalidEncoding, err)
+	}
+	if err := priv.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	count, err := next()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(count) != 4 {
+		return nil, nil, fmt.Errorf("%w: archive entry count", ErrInvalidEncoding)
+	}
+
+	named := make(map[string][]byte)
+	for i := binary.BigEndian.Uint32(count); i > 0; i-- {
+		name, err := next()
+		if err != nil {
+			return nil, nil, err
+		}
+		cipher, err := next()
+		if err != nil {
+			return nil, nil, err
+		}
+		named[string(name)] = cipher
+	}
+
+	return priv, named, nil
+}
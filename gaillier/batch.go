@@ -0,0 +1,112 @@
+package gaillier
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// DecryptBatchLimited decrypts ciphers using at most maxConcurrency goroutines (at least 1)
+// ctx is checked before every item : on cancellation the results decrypted so far are
+// returned (nil for the others) together with ctx.Err()
+// the first decryption error stops the batch and is returned the same way
+func DecryptBatchLimited(ctx context.Context, privkey *PrivKey, ciphers [][]byte, maxConcurrency int) ([][]byte, error) {
+
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+	if maxConcurrency > len(ciphers) {
+		maxConcurrency = len(ciphers)
+	}
+
+	results := make([][]byte, len(ciphers))
+
+	var (
+		mu       sync.Mutex
+		next     int
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	//claim returns the next item to decrypt or -1 when the batch is over
+	claim := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = ctx.Err()
+		}
+		if firstErr != nil || next == len(ciphers) {
+			return -1
+		}
+		next++
+		return next - 1
+	}
+
+	for w := 0; w < maxConcurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := claim(); i >= 0; i = claim() {
+				m, err := Decrypt(privkey, ciphers[i])
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					return
+				}
+				results[i] = m
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results, firstErr
+}
+
+// ReRandomizeBatch re-randomizes every cipher in parallel, one worker per CPU,
+// each drawing its own r values, the output keeps the input order
+func ReRandomizeBatch(pubkey *PubKey, ciphers [][]byte) ([][]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+
+	workers := min(runtime.GOMAXPROCS(0), len(ciphers))
+	results := make([][]byte, len(ciphers))
+
+	var (
+		next     atomic.Int64
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1) - 1); i < len(ciphers); i = int(next.Add(1) - 1) {
+				c, err := ReRandomize(pubkey, ciphers[i])
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					return
+				}
+				results[i] = c
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return results, nil
+}
@@ -0,0 +1,46 @@
+package gaillier
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+/*
+	Blinded decryption
+
+	A client holding a cipher of m gets it decrypted by the key holder without
+	revealing m :
+	1. BlindForDecryption adds a fresh encryption of a uniform mask s, the
+	   blinded cipher encrypts m + s mod n under new randomness
+	2. the key holder decrypts it, m + s mod n is uniform and says nothing of m
+	3. Unblind subtracts s from the returned plaintext
+	The mask must stay with the client and be used once. Nothing here proves
+	the key holder decrypted honestly, a wrong answer unblinds to a wrong m.
+*/
+
+// BlindForDecryption returns cipher blinded by a random mask, and the mask for Unblind
+func BlindForDecryption(pubkey *PubKey, cipher []byte) ([]byte, *big.Int, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	mask, err := rand.Int(rand.Reader, pubkey.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	cm, err := Encrypt(pubkey, mask.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return Add(pubkey, cipher, cm), mask, nil
+}
+
+// Unblind removes mask from the decryption of a blinded cipher, (plaintext - mask) mod n
+func Unblind(plaintext []byte, mask *big.Int, pubkey *PubKey) []byte {
+
+	m := new(big.Int).Sub(new(big.Int).SetBytes(plaintext), mask)
+
+	return m.Mod(m, pubkey.N).Bytes()
+}
@@ -0,0 +1,60 @@
+package gaillier
+
+import (
+	"fmt"
+	"math/big"
+)
+
+/*
+	Bounded ciphertexts
+
+	A BoundedCiphertext carries in clear an upper bound on its non negative
+	plaintext. The Bounded ops compute the bound of their result along with the
+	cipher and refuse to go on once it could reach n, where the plaintext would
+	silently wrap around. The bound is public, it reveals the shape of the
+	computation but nothing about the plaintexts below it.
+*/
+
+// BoundedCiphertext is a cipher whose plaintext is known to be at most Bound
+type BoundedCiphertext struct {
+	Cipher []byte
+	Bound  *big.Int
+}
+
+// EncryptBounded encrypts message with its own value as bound
+func EncryptBounded(pubkey *PubKey, message []byte) (*BoundedCiphertext, error) {
+
+	c, err := Encrypt(pubkey, message)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoundedCiphertext{Cipher: c, Bound: new(big.Int).SetBytes(message)}, nil
+}
+
+// AddBounded adds a and b, the result bound is the sum of their bounds
+func AddBounded(pubkey *PubKey, a, b *BoundedCiphertext) (*BoundedCiphertext, error) {
+	return newBounded(pubkey, Add(pubkey, a.Cipher, b.Cipher), new(big.Int).Add(a.Bound, b.Bound))
+}
+
+// AddConstantBounded adds the plaintext constant k to a, the result bound is a.Bound + k
+func AddConstantBounded(pubkey *PubKey, a *BoundedCiphertext, k []byte) (*BoundedCiphertext, error) {
+	bound := new(big.Int).Add(a.Bound, new(big.Int).SetBytes(k))
+	return newBounded(pubkey, AddConstant(pubkey, a.Cipher, k), bound)
+}
+
+// MulBounded multiplies a by the plaintext constant k, the result bound is a.Bound * k
+func MulBounded(pubkey *PubKey, a *BoundedCiphertext, k []byte) (*BoundedCiphertext, error) {
+	bound := new(big.Int).Mul(a.Bound, new(big.Int).SetBytes(k))
+	return newBounded(pubkey, Mul(pubkey, a.Cipher, k), bound)
+}
+
+// newBounded returns ErrOverflow when bound doesn't fit in Z/nZ
+func newBounded(pubkey *PubKey, cipher []byte, bound *big.Int) (*BoundedCiphertext, error) {
+
+	if bound.Cmp(pubkey.N) >= 0 {
+		return nil, fmt.Errorf("%w: bound has %d bits, n has %d", ErrOverflow, bound.BitLen(), pubkey.N.BitLen())
+	}
+
+	return &BoundedCiphertext{Cipher: cipher, Bound: bound}, nil
+}
@@ -0,0 +1,106 @@
+package gaillier
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+/*
+	DecryptCache serves repeated decryptions of the same cipher bytes from memory
+
+	It keeps plaintexts in memory for as long as they stay cached, anything able
+	to read the process memory (core dumps, swap, debuggers) sees them. Keep the
+	capacity small, Clear the cache when it is no longer needed and don't use it
+	where plaintexts must not outlive their use.
+	Entries are keyed by the SHA-256 of the cipher bytes, a re-randomized cipher
+	is a miss.
+*/
+
+// DecryptCache wraps a private key with a bounded LRU of plaintexts
+// it is safe for concurrent use
+type DecryptCache struct {
+	privkey  *PrivKey
+	mu       sync.Mutex
+	capacity int
+	order    *list.List //most recently used at the front
+	entries  map[[sha256.Size]byte]*list.Element
+}
+
+type cacheEntry struct {
+	fp    [sha256.Size]byte
+	plain []byte
+}
+
+// NewDecryptCache returns a cache of up to capacity plaintexts (at least 1) decrypted with privkey
+// once full the least recently used plaintext is evicted
+func NewDecryptCache(privkey *PrivKey, capacity int) *DecryptCache {
+
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &DecryptCache{
+		privkey:  privkey,
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+// Decrypt returns the plaintext of cipher, from the cache when it was decrypted recently
+func (dc *DecryptCache) Decrypt(cipher []byte) ([]byte, error) {
+
+	fp := sha256.Sum256(cipher)
+
+	dc.mu.Lock()
+	if e, ok := dc.entries[fp]; ok {
+		dc.order.MoveToFront(e)
+		plain := append([]byte(nil), e.Value.(*cacheEntry).plain...)
+		dc.mu.Unlock()
+		return plain, nil
+	}
+	dc.mu.Unlock()
+
+	//decrypt outside the lock, concurrent misses on the same cipher both decrypt
+	plain, err := Decrypt(dc.privkey, cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	if _, ok := dc.entries[fp]; !ok {
+		dc.entries[fp] = dc.order.PushFront(&cacheEntry{fp: fp, plain: append([]byte(nil), plain...)})
+		if dc.order.Len() > dc.capacity {
+			dc.evict(dc.order.Back())
+		}
+	}
+
+	return plain, nil
+}
+
+// Len returns the number of cached plaintexts
+func (dc *DecryptCache) Len() int {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	return dc.order.Len()
+}
+
+// Clear zeroes and drops every cached plaintext
+func (dc *DecryptCache) Clear() {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	for dc.order.Len() > 0 {
+		dc.evict(dc.order.Back())
+	}
+}
+
+// evict zeroes and removes e, dc.mu must be held
+func (dc *DecryptCache) evict(e *list.Element) {
+	entry := e.Value.(*cacheEntry)
+	clear(entry.plain)
+	dc.order.Remove(e)
+	delete(dc.entries, entry.fp)
+}
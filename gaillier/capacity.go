@@ -0,0 +1,71 @@
+package gaillier
+
+import (
+	"math"
+	"math/big"
+)
+
+/*
+	Capacity planning helpers
+
+	Plaintexts live in Z/nZ so any homomorphic sum whose true value reaches n
+	silently wraps around. These helpers let callers size their computations
+	before that happens.
+*/
+
+// AdditiveHeadroom returns how many more additions of a value at most addendMax
+// can be applied to a plaintext currently at most currentMax before it wraps mod n
+// that is floor((n-1-currentMax)/addendMax)
+// a zero addend never wraps and returns math.MaxInt, as does any result that overflows an int
+func (p *PubKey) AdditiveHeadroom(currentMax, addendMax *big.Int) int {
+
+	if addendMax.Sign() <= 0 {
+		return math.MaxInt
+	}
+
+	//room = n - 1 - currentMax
+	room := new(big.Int).Sub(new(big.Int).Sub(p.N, one), currentMax)
+	if room.Sign() <= 0 {
+		return 0
+	}
+
+	k := new(big.Int).Div(room, addendMax)
+	if !k.IsInt64() || k.Int64() > math.MaxInt {
+		return math.MaxInt
+	}
+
+	return int(k.Int64())
+}
+
+// CiphertextBits returns the size in bits of the largest cipher, Nsq.BitLen()
+// that's about 2 * KeyLen whatever the size of the plaintext
+func (p *PubKey) CiphertextBits() int {
+	return p.nsq().BitLen()
+}
+
+// ExpansionFactor returns how many times larger a cipher is than a plaintext
+// of plaintextBits bits, 0 when plaintextBits isn't positive
+func (p *PubKey) ExpansionFactor(plaintextBits int) float64 {
+	if plaintextBits <= 0 {
+		return 0
+	}
+	return float64(p.CiphertextBits()) / float64(plaintextBits)
+}
+
+// CiphertextSize returns the size in bytes of the largest cipher, the width of fixed-width ciphers
+func (p *PubKey) CiphertextSize() int {
+	return (p.CiphertextBits() + 7) / 8
+}
+
+// MaxAdditions returns how many ciphers each encrypting a value below 2^addendBits
+// can be summed before the sum could exceed n-1, that is floor((n-1)/(2^addendBits-1))
+// 0 once 2^addendBits-1 >= n, math.MaxInt when addendBits isn't positive (only zeros)
+func (p *PubKey) MaxAdditions(addendBits int) int {
+
+	if addendBits <= 0 {
+		return math.MaxInt
+	}
+
+	addendMax := new(big.Int).Lsh(one, uint(addendBits))
+	return p.AdditiveHeadroom(new(big.Int), addendMax.Sub(addendMax, one))
+}
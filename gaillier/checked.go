@@ -0,0 +1,42 @@
+package gaillier
+
+/*
+	Checked primitives
+
+	Add, Sum, AddConstant & Mul don't return errors and panic on a malformed key
+	(a nil N for instance). The Checked variants validate the key first and
+	return ErrInvalidKey instead, for servers where a panic is an outage.
+	Every other exported primitive returning an error already validates its key.
+*/
+
+// CheckedAdd is Add returning an error instead of panicking on a malformed key
+func CheckedAdd(pubkey *PubKey, c1, c2 []byte) ([]byte, error) {
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	return Add(pubkey, c1, c2), nil
+}
+
+// CheckedSum is Sum returning an error instead of panicking on a malformed key
+func CheckedSum(pubkey *PubKey, ciphers [][]byte) ([]byte, error) {
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	return Sum(pubkey, ciphers), nil
+}
+
+// CheckedAddConstant is AddConstant returning an error instead of panicking on a malformed key
+func CheckedAddConstant(pubkey *PubKey, cipher, constant []byte) ([]byte, error) {
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	return AddConstant(pubkey, cipher, constant), nil
+}
+
+// CheckedMul is Mul returning an error instead of panicking on a malformed key
+func CheckedMul(pubkey *PubKey, cipher, constant []byte) ([]byte, error) {
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	return Mul(pubkey, cipher, constant), nil
+}
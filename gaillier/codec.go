@@ -0,0 +1,75 @@
+package gaillier
+
+/*
+	Codec typed ciphertexts
+
+	Ciphertext[T] carries its encoding in its type : the codec is a type parameter,
+	never stored, so AddCiphertext on a Ciphertext[Int64Codec] and a Ciphertext[RawCodec]
+	doesn't compile, where AddTagged only finds the mismatch at run time.
+	A codec is an EncodingScheme whose zero value is ready to use, it is instantiated
+	as var codec T wherever a value has to be encoded or decoded.
+	Like Add, AddCiphertext doesn't check both ciphers are under the same key.
+*/
+
+// Codec is an EncodingScheme usable as a Ciphertext type parameter
+type Codec interface {
+	EncodingScheme
+}
+
+// RawCodec encodes []byte as a big endian integer
+type RawCodec struct{ rawScheme }
+
+// Int64Codec encodes int64 with the signed convention of EncryptSigned
+type Int64Codec struct{ int64Scheme }
+
+// Ciphertext is a cipher whose plaintext was encoded with the codec T
+type Ciphertext[T Codec] struct {
+	pubkey *PubKey
+	cipher []byte
+}
+
+// EncryptAs encodes v with the codec T and encrypts it
+func EncryptAs[T Codec](pubkey *PubKey, v any) (Ciphertext[T], error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return Ciphertext[T]{}, err
+	}
+	var codec T
+	m, err := codec.Encode(pubkey.N, v)
+	if err != nil {
+		return Ciphertext[T]{}, err
+	}
+	c, err := Encrypt(pubkey, m.Bytes())
+	if err != nil {
+		return Ciphertext[T]{}, err
+	}
+
+	return Ciphertext[T]{pubkey: pubkey, cipher: c}, nil
+}
+
+// CiphertextAs wraps a cipher encrypted under pubkey whose plaintext was encoded with T
+func CiphertextAs[T Codec](pubkey *PubKey, cipher []byte) Ciphertext[T] {
+	return Ciphertext[T]{pubkey: pubkey, cipher: CloneCiphertext(cipher)}
+}
+
+// Bytes returns a copy of the cipher, mutating it leaves c untouched
+func (c Ciphertext[T]) Bytes() []byte {
+	return CloneCiphertext(c.cipher)
+}
+
+// AddCiphertext adds two ciphers of the same codec, the result is under a's key
+func AddCiphertext[T Codec](a, b Ciphertext[T]) Ciphertext[T] {
+	return Ciphertext[T]{pubkey: a.pubkey, cipher: Add(a.pubkey, a.cipher, b.cipher)}
+}
+
+// DecryptAs decrypts c and decodes the plaintext with the codec T
+func DecryptAs[T Codec](privkey *PrivKey, c Ciphertext[T]) (any, error) {
+
+	m, err := decrypt(privkey, c.cipher)
+	if err != nil {
+		return nil, err
+	}
+	var codec T
+
+	return codec.Decode(privkey.N, m)
+}
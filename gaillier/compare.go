@@ -0,0 +1,69 @@
+package gaillier
+
+import (
+	"fmt"
+	"math/big"
+)
+
+/*
+	Key holder comparisons
+
+	Paillier can't compare plaintexts homomorphically, these helpers are for the
+	private key holder who learns the outcome of the comparison and nothing else
+	is revealed to whoever receives the resulting cipher.
+	Plaintexts are read with the signed convention, see DecryptSigned.
+*/
+
+// EncryptedMax returns a fresh encryption of max(a, b) given ciphers of a and b
+// the key holder learns which one was larger, the returned cipher is re-randomized
+// so it can't be linked to either input
+func (k *PrivKey) EncryptedMax(ca, cb []byte) ([]byte, error) {
+
+	diff, err := DecryptSigned(k, Sub(&k.PubKey, ca, cb))
+	if err != nil {
+		return nil, err
+	}
+
+	if diff.Sign() >= 0 {
+		return ReRandomize(&k.PubKey, ca)
+	}
+
+	return ReRandomize(&k.PubKey, cb)
+}
+
+// Select returns a fresh encryption of cThen's plaintext when encBit encrypts 1
+// and of cElse's when it encrypts 0, any other selector is an error wrapping ErrInvalidEncoding
+// the key holder learns the selector bit, the returned cipher is re-randomized
+// so it can't be linked to either input
+func (k *PrivKey) Select(encBit, cThen, cElse []byte) ([]byte, error) {
+
+	bit, err := decrypt(k, encBit)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bit.Cmp(one) == 0:
+		return ReRandomize(&k.PubKey, cThen)
+	case bit.Sign() == 0:
+		return ReRandomize(&k.PubKey, cElse)
+	}
+
+	return nil, fmt.Errorf("%w: selector is not 0 or 1", ErrInvalidEncoding)
+}
+
+// AboveThreshold reports whether the signed plaintext of cipher is strictly greater than threshold
+// only the boolean leaves the function, the decrypted value is scrubbed before returning
+func (k *PrivKey) AboveThreshold(cipher []byte, threshold *big.Int) (bool, error) {
+
+	if threshold == nil {
+		return false, fmt.Errorf("%w: nil threshold", ErrInvalidEncoding)
+	}
+	m, err := decrypt(k, cipher)
+	if err != nil {
+		return false, err
+	}
+	defer scrub(m)
+
+	return decodeSigned(k.N, m).Cmp(threshold) > 0, nil
+}
@@ -0,0 +1,65 @@
+package gaillier
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+)
+
+// EncryptedCounter is an encrypted signed counter updated with plaintext increments
+// it is safe for concurrent use, the key holder reads it with ReadCounter
+// every update also re-randomizes the cipher (multiplies it by a fresh r^n), otherwise
+// c_after / c_before = g^k would give the increment away to anyone holding the public key
+type EncryptedCounter struct {
+	pubkey *PubKey
+	mu     sync.Mutex
+	acc    *big.Int
+}
+
+// NewEncryptedCounter returns a counter starting at a fresh encryption of zero
+func NewEncryptedCounter(pubkey *PubKey) (*EncryptedCounter, error) {
+
+	c, err := Encrypt(pubkey, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptedCounter{pubkey: pubkey, acc: new(big.Int).SetBytes(c)}, nil
+}
+
+// Inc adds 1 to the counter
+func (ec *EncryptedCounter) Inc() {
+	ec.add(one)
+}
+
+// Add adds k, possibly negative, to the counter
+func (ec *EncryptedCounter) Add(k int64) {
+	ec.add(new(big.Int).Mod(big.NewInt(k), ec.pubkey.N))
+}
+
+// add multiplies the accumulator by g^k * r^n mod n^2, k in [0, n)
+// crypto/rand doesn't fail (since Go 1.24 it aborts the program instead), so neither does add
+func (ec *EncryptedCounter) add(k *big.Int) {
+	r, err := randomUnit(rand.Reader, ec.pubkey.N)
+	if err != nil {
+		panic(err)
+	}
+	gk := ec.pubkey.gExp(k)
+	gk.Mul(gk, modExp(r, ec.pubkey.N, ec.pubkey.nsq())).Mod(gk, ec.pubkey.nsq())
+
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.acc.Mod(ec.acc.Mul(ec.acc, gk), ec.pubkey.nsq())
+}
+
+// Cipher returns the current cipher of the counter
+func (ec *EncryptedCounter) Cipher() []byte {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	return ec.acc.Bytes()
+}
+
+// ReadCounter decrypts the counter with the signed convention of DecryptInt64
+func (k *PrivKey) ReadCounter(c *EncryptedCounter) (int64, error) {
+	return DecryptInt64(k, c.Cipher())
+}
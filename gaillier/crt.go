@@ -0,0 +1,115 @@
+package gaillier
+
+import (
+	"fmt"
+	"math/big"
+)
+
+/*
+	CRT decryption
+
+	Knowing p & q, c^lambda mod n^2 splits into c^(p-1) mod p^2 and c^(q-1) mod q^2 :
+	half sized moduli and exponents make each half about 8 times cheaper than the full exponentiation.
+
+		mp = Lp(c^(p-1) mod p^2) * hp mod p    with Lp(x) = (x-1)/p and hp = Lp(g^(p-1) mod p^2)^-1 mod p
+		mq = Lq(c^(q-1) mod q^2) * hq mod q
+		m  = mq + q * ((mp - mq) * q^-1 mod p)
+*/
+
+// DecryptCRT decrypts cipher like Decrypt using the prime factors P & Q of the key
+// it returns an error wrapping ErrInvalidKey when they are missing or don't factor N
+// the halves are independent, WithParallelCRT(true) computes them concurrently
+func DecryptCRT(privkey *PrivKey, cipher []byte, opts ...DecryptOption) ([]byte, error) {
+
+	cfg := new(decryptConfig)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	m, err := decryptCRT(privkey, cipher, cfg.parallel)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Bytes(), nil
+}
+
+func decryptCRT(privkey *PrivKey, cipher []byte, parallel bool) (*big.Int, error) {
+
+	if err := privkey.PubKey.Validate(); err != nil {
+		return nil, err
+	}
+	p, q := privkey.P, privkey.Q
+	if p == nil || q == nil || new(big.Int).Mul(p, q).Cmp(privkey.N) != 0 {
+		return nil, fmt.Errorf("%w: P and Q must be set and factor N", ErrInvalidKey)
+	}
+
+	c := new(big.Int).SetBytes(cipher)
+
+	if privkey.nsq().Cmp(c) < 1 {
+		return nil, ErrInvalidCiphertext
+	}
+
+	var (
+		mp, mq     *big.Int
+		errP, errQ error
+	)
+	if parallel {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			mq, errQ = crtHalf(privkey, c, q, p)
+		}()
+		mp, errP = crtHalf(privkey, c, p, q)
+		<-done
+	} else {
+		mp, errP = crtHalf(privkey, c, p, q)
+		mq, errQ = crtHalf(privkey, c, q, p)
+	}
+	if errP != nil {
+		return nil, errP
+	}
+	if errQ != nil {
+		return nil, errQ
+	}
+
+	qInv, err := modInverse(q, p)
+	if err != nil {
+		return nil, err
+	}
+
+	//m = mq + q * ((mp - mq) * q^-1 mod p)
+	h := new(big.Int).Sub(mp, mq)
+	h.Mul(h, qInv).Mod(h, p)
+
+	return h.Mul(h, q).Add(h, mq), nil
+}
+
+// crtHalf returns m mod p, other being the second factor of n
+func crtHalf(privkey *PrivKey, c, p, other *big.Int) (*big.Int, error) {
+
+	pSq := new(big.Int).Mul(p, p)
+	pMin := new(big.Int).Sub(p, one)
+
+	var h *big.Int
+	if privkey.G.Cmp(new(big.Int).Add(privkey.N, one)) == 0 {
+		//(1+n)^(p-1) = 1 + (p-1)n mod p^2 so Lp(g^(p-1)) = (p-1)*other = -other mod p
+		h = new(big.Int).Neg(other)
+		h.Mod(h, p)
+	} else {
+		h = crtL(modExp(new(big.Int).Mod(privkey.G, pSq), pMin, pSq), p)
+	}
+	h, err := modInverse(h, p)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	m := crtL(modExp(new(big.Int).Mod(c, pSq), pMin, pSq), p)
+
+	return m.Mul(m, h).Mod(m, p), nil
+}
+
+// crtL is L(x) = (x-1)/p
+func crtL(x, p *big.Int) *big.Int {
+	return x.Sub(x, one).Div(x, p)
+}
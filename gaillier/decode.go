@@ -0,0 +1,59 @@
+package gaillier
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// DecryptBytes decrypts cipher into the big-endian bytes of m, it is Decrypt
+// zero decrypts to an empty slice
+func DecryptBytes(privkey *PrivKey, cipher []byte) ([]byte, error) {
+	return Decrypt(privkey, cipher)
+}
+
+// DecryptBigInt decrypts cipher into m in [0, n)
+func DecryptBigInt(privkey *PrivKey, cipher []byte) (*big.Int, error) {
+	return decrypt(privkey, cipher)
+}
+
+// DecryptInt64 decrypts cipher with the signed convention of DecryptSigned
+// it returns an error wrapping ErrInvalidEncoding when the value doesn't fit an int64
+func DecryptInt64(privkey *PrivKey, cipher []byte) (int64, error) {
+
+	m, err := decrypt(privkey, cipher)
+	if err != nil {
+		return 0, err
+	}
+
+	x := decodeSigned(privkey.N, m)
+	if !x.IsInt64() {
+		return 0, fmt.Errorf("%w: plaintext overflows int64", ErrInvalidEncoding)
+	}
+
+	return x.Int64(), nil
+}
+
+// EncryptInt64 encrypts a possibly negative m with the signed convention, see EncryptSigned
+func EncryptInt64(pubkey *PubKey, m int64) ([]byte, error) {
+	return EncryptSigned(pubkey, big.NewInt(m))
+}
+
+// EncryptUint64 encrypts the unsigned v, it must be smaller than n
+func EncryptUint64(pubkey *PubKey, v uint64) ([]byte, error) {
+	return Encrypt(pubkey, new(big.Int).SetUint64(v).Bytes())
+}
+
+// DecryptUint64 decrypts cipher as an unsigned value
+// it returns an error wrapping ErrInvalidEncoding when the plaintext doesn't fit a uint64
+func DecryptUint64(privkey *PrivKey, cipher []byte) (uint64, error) {
+
+	m, err := decrypt(privkey, cipher)
+	if err != nil {
+		return 0, err
+	}
+	if !m.IsUint64() {
+		return 0, fmt.Errorf("%w: plaintext overflows uint64", ErrInvalidEncoding)
+	}
+
+	return m.Uint64(), nil
+}
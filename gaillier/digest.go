@@ -0,0 +1,29 @@
+package gaillier
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"slices"
+)
+
+// CanonicalDigest returns the SHA-256 of a multiset of ciphers, independent of their order
+// ciphers are sorted by their bytes and hashed length prefixed, duplicates count
+// ciphers are taken as given, encode them the same way (e.g. PackCiphertext) on both sides
+func CanonicalDigest(ciphers [][]byte) []byte {
+
+	sorted := slices.Clone(ciphers)
+	slices.SortFunc(sorted, bytes.Compare)
+
+	h := sha256.New()
+	var l [8]byte
+	binary.BigEndian.PutUint64(l[:], uint64(len(sorted)))
+	h.Write(l[:])
+	for _, c := range sorted {
+		binary.BigEndian.PutUint64(l[:], uint64(len(c)))
+		h.Write(l[:])
+		h.Write(c)
+	}
+
+	return h.Sum(nil)
+}
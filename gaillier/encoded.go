@@ -0,0 +1,138 @@
+package gaillier
+
+import (
+	"fmt"
+	"math/big"
+)
+
+/*
+	Encoded ciphertexts
+
+	An EncodedCiphertext encrypts a mantissa and carries in clear the exponent
+	scaling it (value = mantissa * 10^Exponent) and the fingerprint of the key
+	it was encrypted under. Adding two of them only makes sense when both the key
+	and the exponent match, otherwise the scales are silently mixed up.
+
+	Raw []byte ciphers carry no key information : callers keep the fingerprint of
+	the key next to them and WrapCiphertext checks it against the key (CheckFingerprint),
+	the wrapped cipher then carries it and DecryptEncoded checks it again.
+*/
+
+// EncodedCiphertext is a cipher of a fixed-point mantissa
+type EncodedCiphertext struct {
+	KeyFingerprint string
+	Exponent       int
+	Cipher         []byte
+}
+
+// EncryptEncoded encrypts a non negative mantissa standing for mantissa * 10^exponent
+func EncryptEncoded(pubkey *PubKey, mantissa *big.Int, exponent int) (*EncodedCiphertext, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if mantissa == nil {
+		return nil, fmt.Errorf("%w: nil mantissa", ErrInvalidEncoding)
+	}
+	if mantissa.Sign() < 0 {
+		return nil, fmt.Errorf("%w: negative mantissa", ErrInvalidEncoding)
+	}
+
+	c, err := Encrypt(pubkey, mantissa.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncodedCiphertext{KeyFingerprint: pubkey.Fingerprint(), Exponent: exponent, Cipher: c}, nil
+}
+
+// CheckFingerprint is the key check of raw ciphers : it returns an error wrapping
+// ErrIncompatible unless keyFingerprint (stored next to the cipher) is pubkey's
+func CheckFingerprint(pubkey *PubKey, keyFingerprint string) error {
+
+	if err := pubkey.Validate(); err != nil {
+		return err
+	}
+	if fp := pubkey.Fingerprint(); fp != keyFingerprint {
+		return fmt.Errorf("%w: key fingerprint %s, cipher encrypted under %s", ErrIncompatible, fp, keyFingerprint)
+	}
+
+	return nil
+}
+
+// WrapCiphertext wraps a raw cipher of a mantissa * 10^exponent encrypted under the key
+// of keyFingerprint, for use with pubkey : a fingerprint other than pubkey's returns an
+// error wrapping ErrIncompatible, the cipher is copied
+func WrapCiphertext(pubkey *PubKey, keyFingerprint string, cipher []byte, exponent int) (*EncodedCiphertext, error) {
+
+	if err := CheckFingerprint(pubkey, keyFingerprint); err != nil {
+		return nil, err
+	}
+	if len(cipher) == 0 {
+		return nil, fmt.Errorf("%w: empty cipher", ErrInvalidCiphertext)
+	}
+
+	return &EncodedCiphertext{
+		KeyFingerprint: keyFingerprint,
+		Exponent:       exponent,
+		Cipher:         append([]byte(nil), cipher...),
+	}, nil
+}
+
+// DecryptEncoded returns the mantissa & exponent of an encoded cipher
+func DecryptEncoded(privkey *PrivKey, e *EncodedCiphertext) (*big.Int, int, error) {
+
+	if err := privkey.Validate(); err != nil {
+		return nil, 0, err
+	}
+	if e == nil {
+		return nil, 0, fmt.Errorf("%w: nil encoded cipher", ErrInvalidEncoding)
+	}
+	if fp := privkey.Fingerprint(); fp != e.KeyFingerprint {
+		return nil, 0, fmt.Errorf("%w: key fingerprint %s, cipher encrypted under %s", ErrIncompatible, fp, e.KeyFingerprint)
+	}
+
+	m, err := Decrypt(privkey, e.Cipher)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return new(big.Int).SetBytes(m), e.Exponent, nil
+}
+
+// Compatible returns an error wrapping ErrIncompatible unless a and b
+// were encrypted under the same key with the same exponent
+func Compatible(a, b *EncodedCiphertext) error {
+
+	if a == nil || b == nil {
+		return fmt.Errorf("%w: nil encoded cipher", ErrInvalidEncoding)
+	}
+	if a.KeyFingerprint != b.KeyFingerprint {
+		return fmt.Errorf("%w: key fingerprint %s != %s", ErrIncompatible, a.KeyFingerprint, b.KeyFingerprint)
+	}
+	if a.Exponent != b.Exponent {
+		return fmt.Errorf("%w: exponent %d != %d", ErrIncompatible, a.Exponent, b.Exponent)
+	}
+
+	return nil
+}
+
+// AddEncoded adds two compatible encoded ciphers under pubkey
+func AddEncoded(pubkey *PubKey, a, b *EncodedCiphertext) (*EncodedCiphertext, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if err := Compatible(a, b); err != nil {
+		return nil, err
+	}
+	if fp := pubkey.Fingerprint(); fp != a.KeyFingerprint {
+		return nil, fmt.Errorf("%w: key fingerprint %s != %s", ErrIncompatible, fp, a.KeyFingerprint)
+	}
+
+	return &EncodedCiphertext{
+		KeyFingerprint: a.KeyFingerprint,
+		Exponent:       a.Exponent,
+		Cipher:         Add(pubkey, a.Cipher, b.Cipher),
+	}, nil
+}
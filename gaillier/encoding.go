@@ -0,0 +1,152 @@
+package gaillier
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"slices"
+)
+
+// CloneCiphertext returns a copy of cipher sharing no memory with it, nil for nil
+// ciphers returned by the package are fresh slices, but one stored in a map or cache
+// and handed out again should be cloned so the caller can't mutate the stored bytes
+func CloneCiphertext(c []byte) []byte {
+	return slices.Clone(c)
+}
+
+// CiphertextToHex encodes a cipher as a lowercase big-endian hex string
+// every byte is kept, so fixed-width (zero padded) ciphers keep their width
+func CiphertextToHex(cipher []byte) string {
+	return hex.EncodeToString(cipher)
+}
+
+// CiphertextFromHex decodes a hex string produced by CiphertextToHex
+// odd-length or non hex input returns an error wrapping ErrInvalidEncoding
+func CiphertextFromHex(s string) ([]byte, error) {
+
+	c, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+
+	return c, nil
+}
+
+/*
+	String helpers for scripting & CLIs
+
+	Keys are the base64 (standard encoding) of their gob encoding,
+	messages and ciphers are base64 of their big-endian bytes.
+*/
+
+// PubKeyToBase64 returns the base64 gob encoding of pub, as accepted by EncryptString
+func PubKeyToBase64(pub *PubKey) (string, error) {
+	return gobBase64(pub)
+}
+
+// PrivKeyToBase64 returns the base64 gob encoding of priv, as accepted by DecryptString
+func PrivKeyToBase64(priv *PrivKey) (string, error) {
+	return gobBase64(priv)
+}
+
+// EncryptString encrypts a base64 message under a base64 public key
+// and returns the base64 cipher
+func EncryptString(pubB64, msgB64 string) (string, error) {
+
+	pub := new(PubKey)
+	if err := fromGobBase64(pubB64, pub); err != nil {
+		return "", err
+	}
+	msg, err := base64.StdEncoding.DecodeString(msgB64)
+	if err != nil {
+		return "", fmt.Errorf("%w: message: %v", ErrInvalidEncoding, err)
+	}
+
+	c, err := Encrypt(pub, msg)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(c), nil
+}
+
+// DecryptString decrypts a base64 cipher with a base64 private key
+// and returns the base64 message
+func DecryptString(privB64, cipherB64 string) (string, error) {
+
+	priv := new(PrivKey)
+	if err := fromGobBase64(privB64, priv); err != nil {
+		return "", err
+	}
+	c, err := base64.StdEncoding.DecodeString(cipherB64)
+	if err != nil {
+		return "", fmt.Errorf("%w: cipher: %v", ErrInvalidEncoding, err)
+	}
+
+	m, err := Decrypt(priv, c)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(m), nil
+}
+
+func gobBase64(v interface{}) (string, error) {
+	w := new(bytes.Buffer)
+	if err := gob.NewEncoder(w).Encode(v); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(w.Bytes()), nil
+}
+
+func fromGobBase64(s string, v interface{}) error {
+	buf, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("%w: key: %v", ErrInvalidEncoding, err)
+	}
+	if err := gob.NewDecoder(bytes.NewReader(buf)).Decode(v); err != nil {
+		return fmt.Errorf("%w: key: %v", ErrInvalidEncoding, err)
+	}
+	return nil
+}
+
+// PackCiphertext returns the minimal big-endian encoding of cipher,
+// stripping the leading zero bytes of fixed-width ciphers
+// the range of n^2 is what makes this lossless : every cipher fits in CiphertextSize bytes,
+// so UnpackCiphertext restores the width from the key and no length is stored, the packed
+// form is never longer than CiphertextSize
+// it returns an error next to the bytes, unlike a plain []byte codec, because a cipher >= n^2
+// (or an invalid key) has no packed form : reducing it mod n^2 would change its value and
+// truncating it would lose data, so it returns an error wrapping ErrInvalidEncoding instead
+func PackCiphertext(pubkey *PubKey, cipher []byte) ([]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+
+	c := new(big.Int).SetBytes(cipher)
+	if c.Cmp(pubkey.nsq()) >= 0 {
+		return nil, fmt.Errorf("%w: cipher is not smaller than n^2", ErrInvalidEncoding)
+	}
+
+	return c.Bytes(), nil
+}
+
+// UnpackCiphertext restores a packed cipher to its fixed CiphertextSize width
+// packed values that can't be a cipher (>= n^2) return an error wrapping ErrInvalidEncoding
+func UnpackCiphertext(pubkey *PubKey, packed []byte) ([]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+
+	c := new(big.Int).SetBytes(packed)
+	if c.Cmp(pubkey.nsq()) >= 0 {
+		return nil, fmt.Errorf("%w: packed cipher is not smaller than n^2", ErrInvalidEncoding)
+	}
+
+	return c.FillBytes(make([]byte, pubkey.CiphertextSize())), nil
+}
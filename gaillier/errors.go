@@ -0,0 +1,69 @@
+package gaillier
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorCode identifies a failure mode, it is the number shown in the error message
+type ErrorCode int
+
+// error codes, ErrCodeUnknown is returned by CodeOf for errors from outside the package
+const (
+	ErrCodeUnknown ErrorCode = iota
+	ErrCodeMessageTooLong
+	ErrCodeTagMismatch
+	ErrCodeInvalidPrimes
+	ErrCodeInvalidRandomness
+	ErrCodeTestVectorMismatch
+	ErrCodeInvalidShareCount
+	ErrCodeEmptyMessage
+	ErrCodeInvalidEncoding
+	ErrCodeInvalidKey
+	ErrCodeIncompatible
+	ErrCodeNotInvertible
+	ErrCodeSelfTest
+	ErrCodeRandomSource
+	ErrCodeDimensionMismatch
+	ErrCodeInvalidTranscript
+	ErrCodeOverflow
+	ErrCodeVerification
+	ErrCodeGenerationTimeout
+	ErrCodeInvalidCiphertext
+)
+
+// GaillierError is the type of every Err value of the package
+// errors.Is matches on the code so both errors.Is(err, ErrLongMessage) and
+// errors.Is(err, &GaillierError{Code: ErrCodeMessageTooLong}) hold for wrapped errors
+type GaillierError struct {
+	Code ErrorCode
+	msg  string
+}
+
+func newError(code ErrorCode, msg string) *GaillierError {
+	return &GaillierError{Code: code, msg: msg}
+}
+
+func (e *GaillierError) Error() string {
+	return fmt.Sprintf("Gaillier Error #%d: %s", e.Code, e.msg)
+}
+
+// Is reports whether target is a GaillierError with the same code
+// an invalid ciphertext also matches ErrLongMessage, which decryption returned for it before
+func (e *GaillierError) Is(target error) bool {
+	t, ok := target.(*GaillierError)
+	//a typed nil target (or receiver) has no code to compare
+	if !ok || t == nil || e == nil {
+		return false
+	}
+	return t.Code == e.Code || (e.Code == ErrCodeInvalidCiphertext && t.Code == ErrCodeMessageTooLong)
+}
+
+// CodeOf returns the code of the first GaillierError in err's chain, ErrCodeUnknown if none
+func CodeOf(err error) ErrorCode {
+	var ge *GaillierError
+	if errors.As(err, &ge) {
+		return ge.Code
+	}
+	return ErrCodeUnknown
+}
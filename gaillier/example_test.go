@@ -0,0 +1,99 @@
+package gaillier_test
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+// Generate a key, encrypt, add two ciphers and decrypt the sum
+func Example() {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	a, _ := gaillier.Encrypt(pub, big.NewInt(15).Bytes())
+	b, _ := gaillier.Encrypt(pub, big.NewInt(27).Bytes())
+
+	sum, _ := gaillier.Decrypt(priv, gaillier.Add(pub, a, b))
+	fmt.Println(new(big.Int).SetBytes(sum))
+
+	// zero decrypts to an empty slice, read plaintexts through big.Int
+	zero, _ := gaillier.Encrypt(pub, nil)
+	m, _ := gaillier.Decrypt(priv, zero)
+	fmt.Println(len(m), new(big.Int).SetBytes(m))
+
+	// Output:
+	// 42
+	// 0 0
+}
+
+// Add multiplies the ciphers, which adds the plaintexts
+func ExampleAdd() {
+
+	pub, priv, _ := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	a, _ := gaillier.Encrypt(pub, big.NewInt(100).Bytes())
+	b, _ := gaillier.Encrypt(pub, big.NewInt(23).Bytes())
+
+	m, _ := gaillier.Decrypt(priv, gaillier.Add(pub, a, b))
+	fmt.Println(new(big.Int).SetBytes(m))
+
+	// Output: 123
+}
+
+// Mul raises the cipher to a plaintext constant, which multiplies the plaintext
+func ExampleMul() {
+
+	pub, priv, _ := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	c, _ := gaillier.Encrypt(pub, big.NewInt(12).Bytes())
+
+	m, _ := gaillier.Decrypt(priv, gaillier.Mul(pub, c, big.NewInt(7).Bytes()))
+	fmt.Println(new(big.Int).SetBytes(m))
+
+	// Output: 84
+}
+
+// AddConstant adds a plaintext constant without encrypting it first
+func ExampleAddConstant() {
+
+	pub, priv, _ := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	c, _ := gaillier.Encrypt(pub, big.NewInt(40).Bytes())
+
+	m, _ := gaillier.Decrypt(priv, gaillier.AddConstant(pub, c, big.NewInt(2).Bytes()))
+	fmt.Println(new(big.Int).SetBytes(m))
+
+	// Output: 42
+}
+
+// Enc(5) + Enc(-3) = Enc(2) through the signed codec
+func ExampleDecryptAddSigned() {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	five, _ := gaillier.EncryptSigned(pub, big.NewInt(5))
+	minusThree, _ := gaillier.EncryptSigned(pub, big.NewInt(-3))
+	minusSeven, _ := gaillier.EncryptSigned(pub, big.NewInt(-7))
+
+	sum, _ := gaillier.DecryptAddSigned(priv, five, minusThree)
+	fmt.Println(sum)
+
+	// the same works with Add and DecryptSigned, negative results included
+	neg, _ := gaillier.DecryptSigned(priv, gaillier.Add(pub, five, minusSeven))
+	fmt.Println(neg)
+
+	// Output:
+	// 2
+	// -2
+}
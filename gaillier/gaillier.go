@@ -13,25 +13,86 @@ package gaillier
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/gob"
-	"errors"
+	"fmt"
 	"io"
 	"math/big"
+	"sync/atomic"
+	"time"
 )
 
-//Errors definition
+//Errors definition, each one is a *GaillierError whose code is its number
 
 /* ErrLongMessage The Paillier crypto system picks two keys p & q and denotes n = p*q
 Messages have to be in the ring Z/nZ (integers modulo n)
 Therefore a Message can't be bigger than n
 */
-var ErrLongMessage = errors.New("Gaillier Error #1: Message is too long for The Public-Key Size \n Message should be smaller than Key size you choose")
+var ErrLongMessage = newError(ErrCodeMessageTooLong, "Message is too long for The Public-Key Size \n Message should be smaller than Key size you choose")
+
+// ErrInvalidCiphertext is returned when a cipher to decrypt is not below n^2
+// errors.Is(err, ErrLongMessage) still holds for it
+var ErrInvalidCiphertext = newError(ErrCodeInvalidCiphertext, "Ciphertext is out of range \n Cipher should be smaller than N^2")
+
+// ErrTagMismatch is returned when a tagged ciphertext fails authentication
+var ErrTagMismatch = newError(ErrCodeTagMismatch, "Ciphertext tag mismatch \n Ciphertext or associated data was altered or the MAC key is wrong")
+
+// ErrInvalidPrimes is returned when key generation can't find two distinct non zero primes
+var ErrInvalidPrimes = newError(ErrCodeInvalidPrimes, "Could not generate two distinct primes p & q \n Key size is too small")
+
+// ErrInvalidRandomness is returned when a caller supplied r is not a unit of Z/nZ
+var ErrInvalidRandomness = newError(ErrCodeInvalidRandomness, "Invalid encryption randomness \n r should verify 0 < r < n and gcd(r, n) = 1")
+
+// ErrTestVectorMismatch is returned when a test vector doesn't match this implementation
+var ErrTestVectorMismatch = newError(ErrCodeTestVectorMismatch, "Test vector mismatch")
+
+// ErrInvalidShareCount is returned when asking for less than one share
+var ErrInvalidShareCount = newError(ErrCodeInvalidShareCount, "Invalid share count \n At least one share is required")
+
+// ErrEmptyMessage is returned by EncryptSafe for a zero message unless AllowZeroMessage is set
+var ErrEmptyMessage = newError(ErrCodeEmptyMessage, "Message is zero or empty \n Use AllowZeroMessage to encrypt zero deliberately")
+
+// ErrInvalidEncoding is returned when a textual ciphertext or key can't be decoded
+var ErrInvalidEncoding = newError(ErrCodeInvalidEncoding, "Invalid encoding")
+
+// ErrInvalidKey is returned when a key is missing components or they are inconsistent
+var ErrInvalidKey = newError(ErrCodeInvalidKey, "Invalid key")
+
+// ErrIncompatible is returned when combining ciphers that don't share a key or an encoding
+var ErrIncompatible = newError(ErrCodeIncompatible, "Incompatible ciphertexts")
+
+// ErrNotInvertible is returned when a value has no modular inverse
+var ErrNotInvertible = newError(ErrCodeNotInvertible, "Value is not invertible")
+
+// ErrSelfTest is returned when SelfTest finds an inconsistent result
+var ErrSelfTest = newError(ErrCodeSelfTest, "Self test failed")
+
+// ErrRandomSource is returned when a random source keeps failing or looks stuck
+var ErrRandomSource = newError(ErrCodeRandomSource, "Random source failure")
+
+// ErrDimensionMismatch is returned when vector or matrix operands don't have matching sizes
+var ErrDimensionMismatch = newError(ErrCodeDimensionMismatch, "Mismatched dimensions")
+
+// ErrInvalidTranscript is returned when a transcript op is unknown or refers to a missing operand
+var ErrInvalidTranscript = newError(ErrCodeInvalidTranscript, "Invalid transcript")
+
+// ErrOverflow is returned when a plaintext bound could reach n and wrap around
+var ErrOverflow = newError(ErrCodeOverflow, "Plaintext may overflow the modulus")
+
+// ErrVerification is returned when a result computed by an untrusted party fails its check
+var ErrVerification = newError(ErrCodeVerification, "Result failed verification \n The party that computed it is faulty or dishonest")
+
+// ErrGenerationTimeout is returned when key generation runs past its deadline
+var ErrGenerationTimeout = newError(ErrCodeGenerationTimeout, "Key generation timed out \n Retry with a smaller key size or a faster entropy source")
 
 //constants
 
 var one = big.NewInt(1)
 
+//maxPrimeRetries bounds how many times q is regenerated when it collides with p
+const maxPrimeRetries = 64
+
 //Key structs
 
 // PubKey wraps the public key
@@ -39,9 +100,48 @@ type PubKey struct {
 	KeyLen int
 	N      *big.Int //n = p*q (where p & q are two primes)
 	G      *big.Int //g random integer in Z\*\n^2
-	Nsq    *big.Int //N^2
+	Nsq    *big.Int //N^2, optional : computed on first use when nil
+
+	cache *keyCache //shared by copies of the key, nil for keys built as literals
+}
+
+// keyCache holds the values a key derives from N & G : N^2 on first use, the table of PrecomputeG
+// it sits behind a pointer so that copies of a key (PrivKey embeds PubKey by value)
+// share it instead of copying atomics, entries record the inputs they were computed
+// from and are ignored once the key's N or G changed
+type keyCache struct {
+	nsq atomic.Pointer[nsqEntry]
+	g   atomic.Pointer[gTable] //powers of G, see PrecomputeG
+}
+
+type nsqEntry struct {
+	n, nsq *big.Int
+}
+
+// nsq returns N^2 : Nsq when set, otherwise computed once and cached
+// keys held in large numbers can leave Nsq nil and only pay for N^2 once used
+// (AssemblePubKey & GobDecode give such keys a cache, a literal key recomputes N^2)
+func (p *PubKey) nsq() *big.Int {
+	if p.Nsq != nil {
+		return p.Nsq
+	}
+	if p.cache == nil {
+		return new(big.Int).Mul(p.N, p.N)
+	}
+	if e := p.cache.nsq.Load(); e != nil && e.n.Cmp(p.N) == 0 {
+		return e.nsq
+	}
+	//concurrent first uses may both compute it, they store the same value
+	e := &nsqEntry{n: new(big.Int).Set(p.N), nsq: new(big.Int).Mul(p.N, p.N)}
+	p.cache.nsq.Store(e)
+	return e.nsq
 }
 
+// GobEncode encodes KeyLen, N, G and Nsq (when set) in that order
+// key encodings (gob, base64, MarshalSecretOnly, archives, JSON) are reproducible :
+// the same key always gives the same bytes, so they can be hashed or used as cache keys,
+// cached values (PrecomputeG tables, P & Q) are never encoded
+// MarshalEncryptedPrivKey is the exception, it draws a fresh salt and nonce every time
 func (p *PubKey) GobEncode() ([]byte, error) {
 	w := new(bytes.Buffer)
 	encoder := gob.NewEncoder(w)
@@ -57,9 +157,12 @@ func (p *PubKey) GobEncode() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = encoder.Encode(p.Nsq)
-	if err != nil {
-		return nil, err
+	//Nsq is optional, a key without it decodes without it
+	if p.Nsq != nil {
+		err = encoder.Encode(p.Nsq)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return w.Bytes(), nil
 }
@@ -79,35 +182,170 @@ func (p *PubKey) GobDecode(buf []byte) error {
 	if err != nil {
 		return err
 	}
-	return decoder.Decode(&p.Nsq)
+	err = decoder.Decode(&p.Nsq)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if p.Nsq == nil && p.cache == nil {
+		p.cache = new(keyCache)
+	}
+	return nil
 }
 
 // PrivKey wraps the private key
 type PrivKey struct {
 	KeyLen int
 	PubKey
-	L *big.Int //lcm((p-1)*(q-1))
+	L *big.Int //lambda, (p-1)*(q-1) or lcm(p-1, q-1) with WithCarmichaelLambda
 	U *big.Int //L^-1 modulo n mu = U = (L(g^L mod N^2)^-1)
+	P *big.Int //optional prime factors of N, set by GenerateKeyPair for DecryptCRT
+	Q *big.Int //gob doesn't serialize them (MarshalSecretOnly does), a gob decoded key only decrypts through Decrypt
 }
 
-// GenerateKeyPair generates a private and public key pair.
-func GenerateKeyPair(random io.Reader, bits int) (*PubKey, *PrivKey, error) {
-
-	p, err := rand.Prime(random, bits/2)
+// GobEncode encodes the private key, without it the promoted PubKey.GobEncode
+// would be used and L & U silently dropped
+func (k *PrivKey) GobEncode() ([]byte, error) {
+	w := new(bytes.Buffer)
+	encoder := gob.NewEncoder(w)
+	err := encoder.Encode(k.KeyLen)
+	if err != nil {
+		return nil, err
+	}
+	err = encoder.Encode(&k.PubKey)
+	if err != nil {
+		return nil, err
+	}
+	err = encoder.Encode(k.L)
+	if err != nil {
+		return nil, err
+	}
+	//U can be recomputed from L so it is optional
+	if k.U != nil {
+		err = encoder.Encode(k.U)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return w.Bytes(), nil
+}
 
+// GobDecode decodes a private key, recomputing U when it is missing
+func (k *PrivKey) GobDecode(buf []byte) error {
+	r := bytes.NewBuffer(buf)
+	decoder := gob.NewDecoder(r)
+	err := decoder.Decode(&k.KeyLen)
 	if err != nil {
-		return nil, nil, err
+		return err
+	}
+	err = decoder.Decode(&k.PubKey)
+	if err != nil {
+		return err
 	}
+	err = decoder.Decode(&k.L)
+	if err != nil {
+		return err
+	}
+	k.U = nil
+	err = decoder.Decode(&k.U)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	return k.EnsureU()
+}
 
-	q, err := rand.Prime(random, bits/2)
+// EnsureU computes U = L(g^L mod n^2)^-1 mod n when it is missing
+// (some key formats omit the precomputed mu), it returns ErrInvalidKey if L is missing too
+func (k *PrivKey) EnsureU() error {
 
+	if k.U != nil {
+		return nil
+	}
+	if k.L == nil {
+		return fmt.Errorf("%w: L is missing", ErrInvalidKey)
+	}
+	if err := k.PubKey.Validate(); err != nil {
+		return err
+	}
+
+	//L(g^L mod n^2) with L(x) = x-1 / n
+	x := k.GLModNsq()
+	x.Div(x.Sub(x, one), k.N)
+
+	u, err := modInverse(x, k.N)
 	if err != nil {
-		return nil, nil, err
+		return fmt.Errorf("%w: L(g^L mod n^2) is not invertible mod n", ErrInvalidKey)
 	}
+	k.U = u
 
-	//N = p*q
+	return nil
+}
 
-	n := new(big.Int).Mul(p, q)
+// Lambda returns a copy of the decryption exponent lambda, L
+func (k *PrivKey) Lambda() *big.Int {
+	return new(big.Int).Set(k.L)
+}
+
+// GLModNsq returns g^L mod n^2, the core of mu = U = L(g^L mod n^2)^-1 mod n
+// for any valid key L(GLModNsq()) * U = 1 mod n
+func (k *PrivKey) GLModNsq() *big.Int {
+	return modExp(k.G, k.L, k.nsq())
+}
+
+// GenerateKeyPair generates a private and public key pair.
+// p & q are drawn from random, wrap flaky entropy sources in a RandomSource
+// opts tune the generation, see KeyOption, WithPrimes skips drawing p & q altogether
+// an odd bits is split into two bits/2 bit primes so n has bits-1 bits, KeyLen
+// always reports N.BitLen(), use WithExactBits to get exactly bits
+func GenerateKeyPair(random io.Reader, bits int, opts ...KeyOption) (*PubKey, *PrivKey, error) {
+	return generateKeyPair(newKeyConfig(opts), random, bits)
+}
+
+// GenerateKeyPairContext is GenerateKeyPair stopping early once ctx is done
+// ctx is checked between prime searches, so it overruns by at most one search (a read
+// from random that blocks can't be interrupted either), a passed deadline returns an
+// error wrapping ErrGenerationTimeout and a cancellation returns ctx.Err()
+func GenerateKeyPairContext(ctx context.Context, random io.Reader, bits int, opts ...KeyOption) (*PubKey, *PrivKey, error) {
+	cfg := newKeyConfig(opts)
+	cfg.ctx = ctx
+	return generateKeyPair(cfg, random, bits)
+}
+
+// GenerateKeyPairTimeout is GenerateKeyPairContext with a timeout from now
+// interactive tools can catch ErrGenerationTimeout and offer a smaller size
+func GenerateKeyPairTimeout(random io.Reader, bits int, timeout time.Duration, opts ...KeyOption) (*PubKey, *PrivKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return GenerateKeyPairContext(ctx, random, bits, opts...)
+}
+
+func generateKeyPair(cfg *keyConfig, random io.Reader, bits int) (*PubKey, *PrivKey, error) {
+
+	pBits, qBits := cfg.primeSizes(bits)
+
+	var p, q, n *big.Int
+	for attempt := 0; ; attempt++ {
+		var err error
+		if cfg.primesGiven {
+			p, q, err = cfg.suppliedPrimes(bits)
+		} else {
+			p, q, err = distinctPrimes(cfg, random, pBits, qBits)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		//N = p*q
+		n = new(big.Int).Mul(p, q)
+
+		if (!cfg.exactBits || n.BitLen() == bits) && cfg.gapOK(p, q) {
+			break
+		}
+		if attempt == maxPrimeRetries {
+			return nil, nil, ErrInvalidPrimes
+		}
+	}
+
+	cfg.progress("computing key")
 
 	nSq := new(big.Int).Mul(n, n)
 
@@ -119,42 +357,188 @@ func GenerateKeyPair(random io.Reader, bits int) (*PubKey, *PrivKey, error) {
 	qMin := new(big.Int).Sub(q, one)
 	//(p-1)*(q-1)
 	l := new(big.Int).Mul(pMin, qMin)
+	//lcm(p-1, q-1) = (p-1)*(q-1) / gcd(p-1, q-1)
+	if cfg.carmichael {
+		l.Div(l, new(big.Int).GCD(nil, nil, pMin, qMin))
+	}
 	//l^-1 mod n
-	u := new(big.Int).ModInverse(l, n)
-	pub := &PubKey{KeyLen: bits, N: n, Nsq: nSq, G: g}
-	return pub, &PrivKey{PubKey: *pub, KeyLen: bits, L: l, U: u}, nil
+	u, err := modInverse(l, n)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidPrimes, err)
+	}
+	//KeyLen records the actual size of n, bits-1 for odd bits unless WithExactBits is given
+	pub := &PubKey{KeyLen: n.BitLen(), N: n, Nsq: nSq, G: g}
+	return pub, &PrivKey{PubKey: *pub, KeyLen: pub.KeyLen, L: l, U: u, P: p, Q: q}, nil
+}
+
+// distinctPrimes draws p & q of pBits & qBits bits
+func distinctPrimes(cfg *keyConfig, random io.Reader, pBits, qBits int) (*big.Int, *big.Int, error) {
+
+	cfg.progress("searching p")
+	p, err := cfg.prime(random, pBits)
+
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg.progress("p found")
+
+	cfg.progress("searching q")
+	q, err := cfg.prime(random, qBits)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	//p & q must be distinct otherwise n = p^2 and L = (p-1)^2 which breaks the scheme
+	//collisions only happen in practice for very small bit sizes
+	for i := 0; p.Cmp(q) == 0; i++ {
+		if i == maxPrimeRetries {
+			return nil, nil, ErrInvalidPrimes
+		}
+		q, err = cfg.prime(random, qBits)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if p.Sign() == 0 || q.Sign() == 0 {
+		return nil, nil, ErrInvalidPrimes
+	}
+	cfg.progress("q found")
+
+	return p, q, nil
+}
+
+// CanEncrypt reports whether message fits in the plaintext space Z/nZ,
+// i.e. whether Encrypt would accept it rather than return ErrLongMessage
+func (p *PubKey) CanEncrypt(message []byte) bool {
+	m := new(big.Int).SetBytes(message)
+	return p.N.Cmp(m) >= 1
 }
 
 /*
 	Encrypt encrypts the message into a paillier cipher text
 	using the following rule :
 	cipher = g^m * r^n mod n^2
-	* r is random integer such as 0 <= r <= n
+	* r is random integer such as 0 < r < n and gcd(r, n) = 1
 	* m is the message
 */
-func Encrypt(pubkey *PubKey, message []byte) ([]byte, error) {
+func Encrypt(key PublicKey, message []byte) ([]byte, error) {
+	return EncryptWithReader(rand.Reader, publicOf(key), message)
+}
+
+// EncryptPubKey is Encrypt for a concrete *PubKey
+//
+// Deprecated: use Encrypt, kept for code holding it as a func(*PubKey, []byte) value
+func EncryptPubKey(pubkey *PubKey, message []byte) ([]byte, error) {
+	return Encrypt(pubkey, message)
+}
+
+// EncryptWithReader encrypts like Encrypt drawing r from random
+// wrap flaky entropy sources in a RandomSource to retry transient read failures
+func EncryptWithReader(random io.Reader, pubkey *PubKey, message []byte) ([]byte, error) {
 
-	r, err := rand.Prime(rand.Reader, pubkey.KeyLen)
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if !pubkey.CanEncrypt(message) {
+		return nil, ErrLongMessage
+	}
+
+	r, err := randomUnit(random, pubkey.N)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkReuse(pubkey, r); err != nil {
+		return nil, err
+	}
+	m := new(big.Int).SetBytes(message)
+
+	return encrypt(pubkey, m, r).Bytes(), nil
+}
+
+// EncryptOne returns a fresh encryption of 1
+// Add(pubkey, EncryptOne(), c) encrypts m+1 and Mul(pubkey, EncryptOne(), k) encrypts k
+func (p *PubKey) EncryptOne() ([]byte, error) {
+	return Encrypt(p, one.Bytes())
+}
+
+// EncryptSafe behaves like Encrypt but returns ErrEmptyMessage for a zero / empty message
+// unless AllowZeroMessage is given, since Decrypt returns an empty slice for zero
+// which callers easily mistake for a missing value
+func EncryptSafe(pubkey *PubKey, message []byte, opts ...EncryptOption) ([]byte, error) {
+
+	cfg := newEncryptConfig(opts)
+
+	if !cfg.allowZero && new(big.Int).SetBytes(message).Sign() == 0 {
+		return nil, ErrEmptyMessage
+	}
+
+	return Encrypt(pubkey, message)
+}
 
+// EncryptWithRandomness encrypts message using the caller supplied r
+// r must be a unit of Z/nZ (0 < r < n and gcd(r, n) = 1)
+// reusing r across encryptions links the ciphers, this is meant for test vectors & proofs
+func EncryptWithRandomness(pubkey *PubKey, message []byte, r *big.Int) ([]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if !pubkey.CanEncrypt(message) {
+		return nil, ErrLongMessage
+	}
+	if !isUnit(r, pubkey.N) {
+		return nil, ErrInvalidRandomness
+	}
+	if err := checkReuse(pubkey, r); err != nil {
+		return nil, err
+	}
 	m := new(big.Int).SetBytes(message)
-	if pubkey.N.Cmp(m) < 1 {
+
+	return encrypt(pubkey, m, r).Bytes(), nil
+}
+
+// EncryptTrivial returns the trivial encryption g^m mod n^2, i.e. with r = 1
+// it hides nothing, anyone can recompute it from m : only use it for public
+// constants, AddConstant(c, k) and Add(c, EncryptTrivial(k)) are the same cipher
+func EncryptTrivial(pubkey *PubKey, message []byte) ([]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if !pubkey.CanEncrypt(message) {
 		return nil, ErrLongMessage
 	}
-	//c = g^m * r^nmod n^2
+
+	return pubkey.gExp(new(big.Int).SetBytes(message)).Bytes(), nil
+}
+
+// IsTrivialEncryption reports whether cipher is the unblinded g^m mod n^2 of its
+// own plaintext m, e.g. made by EncryptTrivial where a randomized cipher was expected
+func (k *PrivKey) IsTrivialEncryption(cipher []byte) (bool, error) {
+
+	m, err := decrypt(k, cipher)
+	if err != nil {
+		return false, err
+	}
+
+	c := new(big.Int).SetBytes(cipher)
+
+	return k.gExp(m).Cmp(c) == 0, nil
+}
+
+// encrypt computes c = g^m * r^n mod n^2
+func encrypt(pubkey *PubKey, m, r *big.Int) *big.Int {
 
 	//g^m
-	gm := new(big.Int).Exp(pubkey.G, m, pubkey.Nsq)
+	gm := pubkey.gExp(m)
 	//r^n
-	rn := new(big.Int).Exp(r, pubkey.N, pubkey.Nsq)
+	rn := modExp(r, pubkey.N, pubkey.nsq())
 	//prod = g^m * r^n
 	prod := new(big.Int).Mul(gm, rn)
 
-	c := new(big.Int).Mod(prod, pubkey.Nsq)
-
-	return c.Bytes(), nil
+	return prod.Mod(prod, pubkey.nsq())
 }
 
 /*
@@ -166,23 +550,36 @@ func Encrypt(pubkey *PubKey, message []byte) ([]byte, error) {
 */
 func Decrypt(privkey *PrivKey, cipher []byte) ([]byte, error) {
 
+	m, err := decrypt(privkey, cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.Bytes(), nil
+
+}
+
+// decrypt is the core of Decrypt and its typed variants, it returns m in [0, n)
+func decrypt(privkey *PrivKey, cipher []byte) (*big.Int, error) {
+
+	if err := privkey.Validate(); err != nil {
+		return nil, err
+	}
+
 	c := new(big.Int).SetBytes(cipher)
 
-	if privkey.Nsq.Cmp(c) < 1 {
-		return nil, ErrLongMessage
+	if privkey.nsq().Cmp(c) < 1 {
+		return nil, ErrInvalidCiphertext
 	}
 
 	//c^l mod n^2
-	a := new(big.Int).Exp(c, privkey.L, privkey.Nsq)
+	a := modExp(c, privkey.L, privkey.nsq())
 
 	//L(x) = x-1 / n we compute L(a)
 	l := new(big.Int).Div(new(big.Int).Sub(a, one), privkey.N)
 
 	//computing m
-	m := new(big.Int).Mod(new(big.Int).Mul(l, privkey.U), privkey.N)
-
-	return m.Bytes(), nil
-
+	return new(big.Int).Mod(new(big.Int).Mul(l, privkey.U), privkey.N), nil
 }
 
 /*
@@ -195,39 +592,167 @@ func Decrypt(privkey *PrivKey, cipher []byte) ([]byte, error) {
 */
 
 // Add adds two ciphers together
-func Add(pubkey *PubKey, c1, c2 []byte) []byte {
+func Add(key PublicKey, c1, c2 []byte) []byte {
 
+	pubkey := publicOf(key)
 	a := new(big.Int).SetBytes(c1)
 	b := new(big.Int).SetBytes(c2)
 
 	// a * b mod n^²
-	res := new(big.Int).Mod(new(big.Int).Mul(a, b), pubkey.Nsq)
+	res := new(big.Int).Mod(new(big.Int).Mul(a, b), pubkey.nsq())
 
 	return res.Bytes()
 }
 
+// AddPubKey is Add for a concrete *PubKey
+//
+// Deprecated: use Add, kept for code holding it as a func(*PubKey, []byte, []byte) value
+func AddPubKey(pubkey *PubKey, c1, c2 []byte) []byte {
+	return Add(pubkey, c1, c2)
+}
+
+// Negate returns an encryption of -m mod n for a cipher of m
+// c^(n-1) = c^-1 mod n^2 up to an n-th power, no modular inverse needed
+func Negate(pubkey *PubKey, cipher []byte) []byte {
+
+	c := new(big.Int).SetBytes(cipher)
+
+	//res = c^(n-1) mod n^2
+	res := modExp(c, new(big.Int).Sub(pubkey.N, one), pubkey.nsq())
+
+	return res.Bytes()
+}
+
+// Sub subtracts c2 from c1, the result decrypts to m1 - m2 mod n
+func Sub(pubkey *PubKey, c1, c2 []byte) []byte {
+	return Add(pubkey, c1, Negate(pubkey, c2))
+}
+
+// SubConstant subtracts the constant k from the plaintext, the result decrypts to m - k mod n
+func SubConstant(pubkey *PubKey, cipher, constant []byte) []byte {
+	k := new(big.Int).SetBytes(constant)
+	return AddConstant(pubkey, cipher, k.Mod(k.Neg(k), pubkey.N).Bytes())
+}
+
+// ConstantSub subtracts the plaintext from the constant k, the result decrypts to k - m mod n
+func ConstantSub(pubkey *PubKey, constant, cipher []byte) []byte {
+	return AddConstant(pubkey, Negate(pubkey, cipher), constant)
+}
+
+// Sum adds all the ciphers together
+// the sum of no cipher is the trivial encryption of zero
+// large inputs are split across goroutines, the result is byte-identical to a serial fold
+func Sum(pubkey *PubKey, ciphers [][]byte) []byte {
+
+	return productMod(pubkey.nsq(), len(ciphers), sumChunk, func(i int) *big.Int {
+		return new(big.Int).SetBytes(ciphers[i])
+	}).Bytes()
+}
+
 // AddConstant adds a constant & a cipher
+// plaintexts live in Z/nZ so the constant is reduced mod n first :
+// a constant k >= n adds k mod n, AddConstant(c, n) decrypts to m
 func AddConstant(pubkey *PubKey, cipher, constant []byte) []byte {
 
 	c := new(big.Int).SetBytes(cipher)
 	k := new(big.Int).SetBytes(constant)
+	k.Mod(k, pubkey.N)
 
 	//result = c * g^k mod n^2
 	res := new(big.Int).Mod(
-		new(big.Int).Mul(c, new(big.Int).Exp(pubkey.G, k, pubkey.Nsq)), pubkey.Nsq)
+		new(big.Int).Mul(c, pubkey.gExp(k)), pubkey.nsq())
 
 	return res.Bytes()
 
 }
 
 // Mul multiplies a cipher by a constant integer
-func Mul(pubkey *PubKey, cipher, constant []byte) []byte {
+func Mul(key PublicKey, cipher, constant []byte) []byte {
 
+	pubkey := publicOf(key)
 	c := new(big.Int).SetBytes(cipher)
 	k := new(big.Int).SetBytes(constant)
 
 	//res = c^k mod n^2
-	res := new(big.Int).Exp(c, k, pubkey.Nsq)
+	res := modExp(c, k, pubkey.nsq())
 
 	return res.Bytes()
 }
+
+// MulPubKey is Mul for a concrete *PubKey
+//
+// Deprecated: use Mul, kept for code holding it as a func(*PubKey, []byte, []byte) value
+func MulPubKey(pubkey *PubKey, cipher, constant []byte) []byte {
+	return Mul(pubkey, cipher, constant)
+}
+
+// MulRational multiplies a cipher by num/den
+// the division is an exact division through den^-1 mod n, so the result is
+// only the expected quotient when the plaintext times num is divisible by den
+// it returns ErrNotInvertible when den has no inverse mod n
+func MulRational(pubkey *PubKey, cipher []byte, num, den *big.Int) ([]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if num == nil || den == nil {
+		return nil, fmt.Errorf("%w: nil numerator or denominator", ErrInvalidEncoding)
+	}
+
+	dInv, err := modInverse(den, pubkey.N)
+	if err != nil {
+		return nil, err
+	}
+
+	//k = num * den^-1 mod n
+	k := new(big.Int).Mul(new(big.Int).Mod(num, pubkey.N), dInv)
+	k.Mod(k, pubkey.N)
+
+	return Mul(pubkey, cipher, k.Bytes()), nil
+}
+
+// ReRandomize returns a fresh cipher of the same plaintext
+// cipher * r^n mod n^2 for a random unit r, so it can't be linked to cipher
+func ReRandomize(pubkey *PubKey, cipher []byte) ([]byte, error) {
+	c, _, err := ReRandomizeWithFactor(pubkey, cipher)
+	return c, err
+}
+
+// ReRandomizeWithFactor re-randomizes cipher like ReRandomize and also returns
+// the factor r used, so that newCipher = cipher * r^n mod n^2 can be proven later
+func ReRandomizeWithFactor(pubkey *PubKey, cipher []byte) ([]byte, *big.Int, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	r, err := randomUnit(rand.Reader, pubkey.N)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkReuse(pubkey, r); err != nil {
+		return nil, nil, err
+	}
+
+	c := new(big.Int).SetBytes(cipher)
+	c.Mul(c, modExp(r, pubkey.N, pubkey.nsq()))
+	c.Mod(c, pubkey.nsq())
+
+	return c.Bytes(), r, nil
+}
+
+// modInverse returns a^-1 mod m, a may be negative or larger than m
+// it returns ErrNotInvertible when gcd(a, m) != 1 (or m < 2) where big.Int.ModInverse returns nil
+func modInverse(a, m *big.Int) (*big.Int, error) {
+
+	if m.Cmp(one) <= 0 {
+		return nil, fmt.Errorf("%w: modulus %v", ErrNotInvertible, m)
+	}
+
+	inv := new(big.Int).ModInverse(new(big.Int).Mod(a, m), m)
+	if inv == nil {
+		return nil, ErrNotInvertible
+	}
+
+	return inv, nil
+}
@@ -0,0 +1,57 @@
+package gaillier
+
+import (
+	"fmt"
+)
+
+/*
+	Encrypted histograms
+
+	Each record adds 1 to exactly one of k encrypted bins. IncrementBin only
+	touches the chosen bin, so anyone comparing the bins before and after an
+	update learns which bin was incremented, though not its count. Hiding the
+	index means adding an encryption of 0 or 1 to every bin on each update.
+*/
+
+// NewHistogram returns k bins each holding a fresh encryption of zero
+func NewHistogram(pubkey *PubKey, k int) ([][]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if k < 0 {
+		return nil, fmt.Errorf("%w: %d bins", ErrDimensionMismatch, k)
+	}
+
+	bins := make([][]byte, k)
+	for i := range bins {
+		c, err := Encrypt(pubkey, nil)
+		if err != nil {
+			return nil, err
+		}
+		bins[i] = c
+	}
+
+	return bins, nil
+}
+
+// IncrementBin adds a fresh encryption of 1 to bins[index], replacing it in the slice
+// it leaks index to anyone watching which bin changed, see the note above
+// an index out of range returns an error wrapping ErrDimensionMismatch
+func IncrementBin(pubkey *PubKey, bins [][]byte, index int) error {
+
+	if err := pubkey.Validate(); err != nil {
+		return err
+	}
+	if index < 0 || index >= len(bins) {
+		return fmt.Errorf("%w: bin %d out of range [0, %d)", ErrDimensionMismatch, index, len(bins))
+	}
+
+	c, err := Encrypt(pubkey, one.Bytes())
+	if err != nil {
+		return err
+	}
+	bins[index] = Add(pubkey, bins[index], c)
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package gaillier
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"math/big"
+)
+
+/*
+	Keyed encryption
+
+	EncryptKeyed derives r from HMAC-SHA256(masterSecret, recordID) instead of
+	drawing it, so the exact cipher of a record can be recomputed later :
+	- same (masterSecret, recordID, message) under the same key gives the same cipher
+	- different recordIDs give unrelated r, equal messages don't show
+	- without masterSecret r is unpredictable and the cipher is as hiding as Encrypt
+	- with masterSecret and the recordID anyone recovers m : c / r^n = g^m mod n^2,
+	  guard masterSecret like the private key
+	- a recordID must not be reused for a different message, two ciphers with the
+	  same r reveal the difference of their plaintexts
+*/
+
+// EncryptKeyed encrypts message with r derived from masterSecret and recordID
+func EncryptKeyed(pubkey *PubKey, masterSecret, recordID, message []byte) ([]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if !pubkey.CanEncrypt(message) {
+		return nil, ErrLongMessage
+	}
+
+	r, err := randomUnit(newKeyedReader(masterSecret, pubkey, recordID), pubkey.N)
+	if err != nil {
+		return nil, err
+	}
+
+	return encrypt(pubkey, new(big.Int).SetBytes(message), r).Bytes(), nil
+}
+
+// keyedReader is the stream HMAC(secret, fingerprint || len(recordID) || recordID || counter)
+// binding r to the public key so a secret shared by several keys gives unrelated r
+type keyedReader struct {
+	mac     hash.Hash
+	prefix  []byte
+	counter uint64
+	block   []byte
+}
+
+func newKeyedReader(secret []byte, pubkey *PubKey, recordID []byte) *keyedReader {
+	prefix := append([]byte(pubkey.Fingerprint()), binary.BigEndian.AppendUint64(nil, uint64(len(recordID)))...)
+	return &keyedReader{mac: hmac.New(sha256.New, secret), prefix: append(prefix, recordID...)}
+}
+
+func (k *keyedReader) Read(p []byte) (int, error) {
+
+	n := 0
+	for n < len(p) {
+		if len(k.block) == 0 {
+			k.mac.Reset()
+			k.mac.Write(k.prefix)
+			k.mac.Write(binary.BigEndian.AppendUint64(nil, k.counter))
+			k.counter++
+			k.block = k.mac.Sum(nil)
+		}
+		c := copy(p[n:], k.block)
+		k.block = k.block[c:]
+		n += c
+	}
+
+	return n, nil
+}
@@ -0,0 +1,137 @@
+package gaillier
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+/*
+	Streaming key export
+
+	Keys are written as frames of the ciphertext stream format (4 byte big-endian length + bytes) :
+	a one byte kind, KeyLen as 4 bytes big-endian, N and G, then L and U for a private key.
+	Nsq is recomputed on read, so the encoding is about half the size of the gob one.
+*/
+
+const (
+	keyKindPublic  = 0x01
+	keyKindPrivate = 0x02
+)
+
+// WriteTo writes the public key to w, it implements io.WriterTo
+func (p *PubKey) WriteTo(w io.Writer) (int64, error) {
+
+	if err := p.Validate(); err != nil {
+		return 0, err
+	}
+
+	return writeKeyFrames(w, keyKindPublic, p.KeyLen, p.N, p.G)
+}
+
+// WriteTo writes the private key to w, it implements io.WriterTo
+func (k *PrivKey) WriteTo(w io.Writer) (int64, error) {
+
+	if err := k.Validate(); err != nil {
+		return 0, err
+	}
+
+	return writeKeyFrames(w, keyKindPrivate, k.KeyLen, k.N, k.G, k.L, k.U)
+}
+
+// ReadFrom reads a public key written by PubKey.WriteTo into p, it implements io.ReaderFrom
+// only the bytes of the key are consumed, r can carry more data after it
+func (p *PubKey) ReadFrom(r io.Reader) (int64, error) {
+
+	cr := &CountingReader{R: r}
+	keyLen, ints, err := readKeyFrames(cr, keyKindPublic, 2)
+	if err != nil {
+		return cr.N, err
+	}
+
+	key := PubKey{KeyLen: keyLen, N: ints[0], G: ints[1], Nsq: new(big.Int).Mul(ints[0], ints[0])}
+	if err := key.Validate(); err != nil {
+		return cr.N, err
+	}
+	*p = key
+
+	return cr.N, nil
+}
+
+// ReadFrom reads a private key written by PrivKey.WriteTo into k, it implements io.ReaderFrom
+func (k *PrivKey) ReadFrom(r io.Reader) (int64, error) {
+
+	cr := &CountingReader{R: r}
+	keyLen, ints, err := readKeyFrames(cr, keyKindPrivate, 4)
+	if err != nil {
+		return cr.N, err
+	}
+
+	n := ints[0]
+	pub := PubKey{KeyLen: keyLen, N: n, G: ints[1], Nsq: new(big.Int).Mul(n, n)}
+	key := PrivKey{KeyLen: keyLen, PubKey: pub, L: ints[2], U: ints[3]}
+	if err := key.Validate(); err != nil {
+		return cr.N, err
+	}
+	*k = key
+
+	return cr.N, nil
+}
+
+func writeKeyFrames(w io.Writer, kind byte, keyLen int, ints ...*big.Int) (int64, error) {
+
+	buf := new(bytes.Buffer)
+	cw := NewCiphertextWriter(buf)
+	frames := [][]byte{{kind}, binary.BigEndian.AppendUint32(nil, uint32(keyLen))}
+	for _, x := range ints {
+		frames = append(frames, x.Bytes())
+	}
+	for _, f := range frames {
+		if err := cw.Write(f); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// readKeyFrames reads the kind, KeyLen and count integers, a missing frame is an error
+func readKeyFrames(r io.Reader, kind byte, count int) (int, []*big.Int, error) {
+
+	cr := NewCiphertextReader(r)
+	next := func() ([]byte, error) {
+		f, err := cr.Read()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: truncated key: %w", ErrInvalidEncoding, io.ErrUnexpectedEOF)
+		}
+		return f, err
+	}
+
+	f, err := next()
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(f) != 1 || f[0] != kind {
+		return 0, nil, fmt.Errorf("%w: unexpected key kind", ErrInvalidEncoding)
+	}
+	if f, err = next(); err != nil {
+		return 0, nil, err
+	}
+	if len(f) != 4 {
+		return 0, nil, fmt.Errorf("%w: malformed key length", ErrInvalidEncoding)
+	}
+	keyLen := int(binary.BigEndian.Uint32(f))
+
+	ints := make([]*big.Int, count)
+	for i := range ints {
+		if f, err = next(); err != nil {
+			return 0, nil, err
+		}
+		ints[i] = new(big.Int).SetBytes(f)
+	}
+
+	return keyLen, ints, nil
+}
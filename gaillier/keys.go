@@ -0,0 +1,344 @@
+package gaillier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// MarshalSecretOnly serializes only the secret part of the key (KeyLen, L, U and P & Q when set)
+// for storage next to a separately distributed public key, see ReconstructPrivKey
+// U is optional, it is written as 0 when missing but P & Q follow
+func (k *PrivKey) MarshalSecretOnly() ([]byte, error) {
+	w := new(bytes.Buffer)
+	encoder := gob.NewEncoder(w)
+	err := encoder.Encode(k.KeyLen)
+	if err != nil {
+		return nil, err
+	}
+	err = encoder.Encode(k.L)
+	if err != nil {
+		return nil, err
+	}
+	withPQ := k.P != nil && k.Q != nil
+	if k.U != nil || withPQ {
+		u := k.U
+		if u == nil {
+			u = new(big.Int)
+		}
+		err = encoder.Encode(u)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if withPQ {
+		for _, x := range []*big.Int{k.P, k.Q} {
+			if err := encoder.Encode(x); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return w.Bytes(), nil
+}
+
+// ReconstructPrivKey rebuilds a private key from its public key and the output of MarshalSecretOnly
+// the secret must belong to pub : a mismatch returns an error wrapping ErrInvalidKey
+func ReconstructPrivKey(pub *PubKey, secret []byte) (*PrivKey, error) {
+
+	if err := pub.Validate(); err != nil {
+		return nil, err
+	}
+
+	k := &PrivKey{PubKey: *pub}
+
+	decoder := gob.NewDecoder(bytes.NewReader(secret))
+	err := decoder.Decode(&k.KeyLen)
+	if err != nil {
+		return nil, err
+	}
+	err = decoder.Decode(&k.L)
+	if err != nil {
+		return nil, err
+	}
+	var u *big.Int
+	err = decoder.Decode(&u)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if u != nil && u.Sign() != 0 {
+		k.U = u
+	}
+	if err == nil {
+		var p, q *big.Int
+		err = decoder.Decode(&p)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if err == nil {
+			if err := decoder.Decode(&q); err != nil {
+				return nil, err
+			}
+			k.P, k.Q = p, q
+		}
+	}
+
+	if k.L == nil {
+		return nil, fmt.Errorf("%w: L is missing", ErrInvalidKey)
+	}
+	if err := k.EnsureU(); err != nil {
+		return nil, err
+	}
+	if err := k.consistent(); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// Fingerprint identifies the public key : the hex SHA-256 of its length prefixed N and G
+// two keys with the same fingerprint encrypt into the same group
+func (p *PubKey) Fingerprint() string {
+
+	h := sha256.New()
+	for _, x := range []*big.Int{p.N, p.G} {
+		b := x.Bytes()
+		var l [8]byte
+		binary.BigEndian.PutUint64(l[:], uint64(len(b)))
+		h.Write(l[:])
+		h.Write(b)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Public returns a deep copy of the public part of the key
+// unlike k.PubKey it shares no big.Int with the private key, so it can be
+// handed to untrusted code without aliasing private internals
+// it shares the key's cache (N^2 and the PrecomputeG table), which only holds public values
+func (k *PrivKey) Public() *PubKey {
+	return &PubKey{
+		KeyLen: k.PubKey.KeyLen,
+		N:      new(big.Int).Set(k.N),
+		G:      new(big.Int).Set(k.G),
+		Nsq:    new(big.Int).Set(k.nsq()),
+		cache:  k.cache,
+	}
+}
+
+// PublicKey is the key accepted by Encrypt, Add & Mul : a *PubKey or a *PrivKey
+// *PrivKey satisfies it only because it embeds PubKey, code that just encrypts or
+// evaluates should be handed priv.Public() so it never holds L & U, keeping the
+// secret in as few places as possible
+// the method is unexported, no other type can implement it
+type PublicKey interface {
+	publicKey() *PubKey
+}
+
+func (p *PubKey) publicKey() *PubKey {
+	return p
+}
+
+func (k *PrivKey) publicKey() *PubKey {
+	if k == nil {
+		return nil
+	}
+	return &k.PubKey
+}
+
+// publicOf returns the *PubKey behind key, nil for a nil key
+func publicOf(key PublicKey) *PubKey {
+	if key == nil {
+		return nil
+	}
+	return key.publicKey()
+}
+
+// NewPubKeyFromN builds the public key of modulus n with the standard g = n+1
+// n must be odd and greater than 1, KeyLen is n's bit length
+func NewPubKeyFromN(n *big.Int) (*PubKey, error) {
+
+	if n == nil || n.Cmp(one) <= 0 || n.Bit(0) == 0 {
+		return nil, fmt.Errorf("%w: modulus must be odd and greater than 1", ErrInvalidKey)
+	}
+
+	n = new(big.Int).Set(n)
+
+	return &PubKey{
+		KeyLen: n.BitLen(),
+		N:      n,
+		G:      new(big.Int).Add(n, one),
+		Nsq:    new(big.Int).Mul(n, n),
+	}, nil
+}
+
+// Validate checks the public key is complete and consistent :
+// N > 1, Nsq = N^2 when set and 0 < G < N^2
+// every error returning primitive validates its key so a malformed key yields ErrInvalidKey instead of a panic
+func (p *PubKey) Validate() error {
+
+	switch {
+	case p == nil:
+		return fmt.Errorf("%w: nil key", ErrInvalidKey)
+	case p.N == nil || p.G == nil:
+		return fmt.Errorf("%w: N and G must be set", ErrInvalidKey)
+	case p.N.Cmp(one) <= 0:
+		return fmt.Errorf("%w: N must be greater than 1", ErrInvalidKey)
+	case p.Nsq != nil && p.Nsq.Cmp(new(big.Int).Mul(p.N, p.N)) != 0:
+		return fmt.Errorf("%w: Nsq is not N^2", ErrInvalidKey)
+	case p.G.Sign() <= 0 || p.G.Cmp(p.nsq()) >= 0:
+		return fmt.Errorf("%w: G must verify 0 < G < N^2", ErrInvalidKey)
+	}
+
+	return nil
+}
+
+// Validate checks the public part of the key and that L & U are set
+func (k *PrivKey) Validate() error {
+
+	if k == nil {
+		return fmt.Errorf("%w: nil key", ErrInvalidKey)
+	}
+	if err := k.PubKey.Validate(); err != nil {
+		return err
+	}
+	if k.L == nil || k.L.Sign() <= 0 || k.U == nil || k.U.Sign() <= 0 {
+		return fmt.Errorf("%w: L and U must be set", ErrInvalidKey)
+	}
+
+	return nil
+}
+
+// ValidatePair checks that priv is the private key of pub : same N & G, and a
+// random probe encrypted with pub decrypts back under priv
+// mismatches return an error wrapping ErrIncompatible
+func ValidatePair(pub *PubKey, priv *PrivKey) error {
+
+	if err := pub.Validate(); err != nil {
+		return err
+	}
+	if err := priv.Validate(); err != nil {
+		return err
+	}
+	if pub.N.Cmp(priv.N) != 0 {
+		return fmt.Errorf("%w: moduli differ, public key %s private key %s", ErrIncompatible, pub.Fingerprint(), priv.Fingerprint())
+	}
+	if pub.G.Cmp(priv.G) != 0 {
+		return fmt.Errorf("%w: generators differ", ErrIncompatible)
+	}
+
+	probe, err := rand.Int(rand.Reader, pub.N)
+	if err != nil {
+		return err
+	}
+	c, err := Encrypt(pub, probe.Bytes())
+	if err != nil {
+		return err
+	}
+	m, err := decrypt(priv, c)
+	if err != nil {
+		return err
+	}
+	if m.Cmp(probe) != 0 {
+		return fmt.Errorf("%w: probe decrypted to a different value, L or U don't match the key", ErrIncompatible)
+	}
+
+	return nil
+}
+
+// AssemblePubKey builds a public key straight from its components, nsq may be nil
+// UNSAFE-FAST : nothing is checked or copied, the key aliases n, g & nsq
+// only for components that come from a trusted source (a cache of keys that were
+// validated when first stored), use NewPubKey for anything else
+func AssemblePubKey(keyLen int, n, g, nsq *big.Int) *PubKey {
+	p := &PubKey{KeyLen: keyLen, N: n, G: g, Nsq: nsq}
+	if nsq == nil {
+		p.cache = new(keyCache)
+	}
+	return p
+}
+
+// AssemblePrivKey builds the private key of pub from L & U
+// UNSAFE-FAST like AssemblePubKey : nothing is checked, l & u are aliased and the
+// public part is a shallow copy of pub, use NewPrivKey for untrusted components
+func AssemblePrivKey(pub *PubKey, l, u *big.Int) *PrivKey {
+	return &PrivKey{KeyLen: pub.KeyLen, PubKey: *pub, L: l, U: u}
+}
+
+// NewPubKey is the validated AssemblePubKey : the components are copied and the key
+// must pass Validate with keyLen = N.BitLen()
+func NewPubKey(keyLen int, n, g, nsq *big.Int) (*PubKey, error) {
+
+	if n == nil || g == nil {
+		return nil, fmt.Errorf("%w: N and G must be set", ErrInvalidKey)
+	}
+
+	p := AssemblePubKey(keyLen, new(big.Int).Set(n), new(big.Int).Set(g), nil)
+	if nsq != nil {
+		p.Nsq = new(big.Int).Set(nsq)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if keyLen != n.BitLen() {
+		return nil, fmt.Errorf("%w: KeyLen %d but N has %d bits", ErrInvalidKey, keyLen, n.BitLen())
+	}
+
+	return p, nil
+}
+
+// NewPrivKey is the validated AssemblePrivKey : pub must be valid, l & u are copied
+// and must match N, see consistent
+func NewPrivKey(pub *PubKey, l, u *big.Int) (*PrivKey, error) {
+
+	if err := pub.Validate(); err != nil {
+		return nil, err
+	}
+	if l == nil || u == nil {
+		return nil, fmt.Errorf("%w: L and U must be set", ErrInvalidKey)
+	}
+
+	k := AssemblePrivKey(pub, new(big.Int).Set(l), new(big.Int).Set(u))
+	if err := k.consistent(); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// consistent checks the secret part of k against N : P*Q = N when P & Q are set,
+// L is a multiple of lambda (w^L = 1 mod n for two random units w, any other L fails
+// each with probability at least 1/2) and L(g^L mod n^2) * U = 1 mod n
+func (k *PrivKey) consistent() error {
+
+	if err := k.Validate(); err != nil {
+		return err
+	}
+	if (k.P == nil) != (k.Q == nil) || (k.P != nil && new(big.Int).Mul(k.P, k.Q).Cmp(k.N) != 0) {
+		return fmt.Errorf("%w: P and Q don't factor N", ErrInvalidKey)
+	}
+
+	for i := 0; i < 2; i++ {
+		w, err := randomUnit(rand.Reader, k.N)
+		if err != nil {
+			return err
+		}
+		if w.Exp(w, k.L, k.N).Cmp(one) != 0 {
+			return fmt.Errorf("%w: L is not a multiple of lambda(N)", ErrInvalidKey)
+		}
+	}
+
+	x := k.GLModNsq()
+	x.Div(x.Sub(x, one), k.N)
+	x.Mul(x, k.U).Mod(x, k.N)
+	if x.Cmp(one) != 0 {
+		return fmt.Errorf("%w: U is not the inverse of L(g^L mod n^2)", ErrInvalidKey)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package gaillier
+
+import (
+	"fmt"
+	"math/big"
+)
+
+/*
+	Multi-cipher plaintexts
+
+	A value m >= n is stored as its base n digits m = d_0 + d_1*n + d_2*n^2 + ...
+	each digit encrypted on its own, least significant first.
+	This is a storage format : adding two such lists limb by limb doesn't
+	propagate carries, a limb sum reaching n wraps around and the recombined
+	value is wrong. Only use homomorphic ops on the limbs when the caller knows
+	no limb can overflow.
+*/
+
+// EncryptBig encrypts a non negative m of any size as a list of base n limbs
+func EncryptBig(pubkey *PubKey, m *big.Int) ([][]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, fmt.Errorf("%w: nil value", ErrInvalidEncoding)
+	}
+	if m.Sign() < 0 {
+		return nil, fmt.Errorf("%w: negative value", ErrInvalidEncoding)
+	}
+
+	rest := new(big.Int).Set(m)
+	var ciphers [][]byte
+	for {
+		digit := new(big.Int)
+		rest.QuoRem(rest, pubkey.N, digit)
+
+		c, err := Encrypt(pubkey, digit.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		ciphers = append(ciphers, c)
+
+		if rest.Sign() == 0 {
+			return ciphers, nil
+		}
+	}
+}
+
+// DecryptBig decrypts the limbs produced by EncryptBig and recombines them
+func DecryptBig(privkey *PrivKey, ciphers [][]byte) (*big.Int, error) {
+
+	if len(ciphers) == 0 {
+		return nil, fmt.Errorf("%w: no limbs", ErrInvalidEncoding)
+	}
+
+	m := new(big.Int)
+	for i := len(ciphers) - 1; i >= 0; i-- {
+		d, err := Decrypt(privkey, ciphers[i])
+		if err != nil {
+			return nil, err
+		}
+		m.Mul(m, privkey.N)
+		m.Add(m, new(big.Int).SetBytes(d))
+	}
+
+	return m, nil
+}
+
+// chunkMarker prefixes every chunk so leading zero bytes of the data survive decryption
+const chunkMarker = 0x01
+
+// chunkSize returns how many message bytes fit one chunk : marker and data must stay
+// below 2^(bits-1) <= n, so (bits-1)/8 bytes of which one is the marker
+func chunkSize(n *big.Int) int {
+	return (n.BitLen()-1)/8 - 1
+}
+
+// EncryptChunks splits message in blocks of chunkSize bytes, the last one possibly shorter,
+// and encrypts each prefixed by a marker byte, an empty message gives a single chunk
+// unlike EncryptBig the chunks hold bytes, not digits : leading zeros and the exact length are kept
+func EncryptChunks(pubkey *PubKey, message []byte) ([][]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	size := chunkSize(pubkey.N)
+	if size < 1 {
+		return nil, fmt.Errorf("%w: key too small to hold a chunk", ErrLongMessage)
+	}
+
+	ciphers := make([][]byte, 0, len(message)/size+1)
+	for {
+		block := message[:min(size, len(message))]
+		message = message[len(block):]
+
+		c, err := Encrypt(pubkey, append([]byte{chunkMarker}, block...))
+		if err != nil {
+			return nil, err
+		}
+		ciphers = append(ciphers, c)
+
+		if len(message) == 0 {
+			return ciphers, nil
+		}
+	}
+}
+
+// DecryptChunks decrypts the chunks produced by EncryptChunks and concatenates them
+// a missing marker or a short chunk before the last one returns an error wrapping ErrInvalidEncoding
+func DecryptChunks(privkey *PrivKey, chunks [][]byte) ([]byte, error) {
+
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("%w: no chunks", ErrInvalidEncoding)
+	}
+	if err := privkey.Validate(); err != nil {
+		return nil, err
+	}
+	size := chunkSize(privkey.N)
+
+	message := make([]byte, 0, len(chunks)*size)
+	for i, c := range chunks {
+		d, err := Decrypt(privkey, c)
+		if err != nil {
+			return nil, err
+		}
+		if len(d) == 0 || d[0] != chunkMarker {
+			return nil, fmt.Errorf("%w: chunk %d has no marker", ErrInvalidEncoding, i)
+		}
+		d = d[1:]
+		if len(d) > size || (i < len(chunks)-1 && len(d) != size) {
+			return nil, fmt.Errorf("%w: chunk %d holds %d bytes, want %d", ErrInvalidEncoding, i, len(d), size)
+		}
+		message = append(message, d...)
+	}
+
+	return message, nil
+}
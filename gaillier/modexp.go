@@ -0,0 +1,45 @@
+package gaillier
+
+import (
+	"math/big"
+	"sync/atomic"
+)
+
+/*
+	Modular exponentiation backend
+
+	Every exponentiation mod n^2 done by Encrypt, Decrypt and the homomorphic
+	ops goes through a ModExp, big.Int.Exp by default. SetModExp swaps it
+	package wide for a faster backend (GMP bindings, hardware accelerators).
+	The hook is called from several goroutines at once (Sum, DotProduct,
+	DecryptBatchLimited) so it must be safe for concurrent use, and it must not
+	modify its arguments.
+*/
+
+// ModExp returns base^exp mod mod as a new big.Int
+type ModExp func(base, exp, mod *big.Int) *big.Int
+
+var modExpHook atomic.Pointer[ModExp]
+
+// SetModExp installs f as the package wide ModExp and returns the previous one
+// a nil f restores big.Int.Exp
+func SetModExp(f ModExp) ModExp {
+	var prev *ModExp
+	if f == nil {
+		prev = modExpHook.Swap(nil)
+	} else {
+		prev = modExpHook.Swap(&f)
+	}
+	if prev == nil {
+		return nil
+	}
+	return *prev
+}
+
+// modExp computes base^exp mod mod with the installed backend
+func modExp(base, exp, mod *big.Int) *big.Int {
+	if f := modExpHook.Load(); f != nil {
+		return (*f)(base, exp, mod)
+	}
+	return new(big.Int).Exp(base, exp, mod)
+}
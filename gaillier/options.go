@@ -0,0 +1,250 @@
+package gaillier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// EncryptOption configures EncryptSafe
+type EncryptOption func(*encryptConfig)
+
+type encryptConfig struct {
+	allowZero bool
+}
+
+func newEncryptConfig(opts []EncryptOption) *encryptConfig {
+	cfg := new(encryptConfig)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// AllowZeroMessage lets EncryptSafe encrypt a zero / empty message
+func AllowZeroMessage() EncryptOption {
+	return func(c *encryptConfig) {
+		c.allowZero = true
+	}
+}
+
+// DecryptOption configures DecryptCRT
+type DecryptOption func(*decryptConfig)
+
+type decryptConfig struct {
+	parallel bool
+}
+
+// WithParallelCRT makes DecryptCRT run its two half exponentiations in two goroutines
+// it only pays off with a spare CPU and large keys, the goroutine handoff costs a few
+// microseconds against milliseconds of exponentiation for 3072 bits and up
+func WithParallelCRT(parallel bool) DecryptOption {
+	return func(c *decryptConfig) {
+		c.parallel = parallel
+	}
+}
+
+// MoveOption configures Permute and SelectIndices
+type MoveOption func(*moveConfig)
+
+type moveConfig struct {
+	rerandomize *PubKey
+}
+
+// ReRandomizeOnMove makes Permute and SelectIndices re-randomize every cipher they output
+// under pubkey, so the output can't be matched byte for byte against the input
+// without it anyone holding both vectors can recover the permutation
+func ReRandomizeOnMove(pubkey *PubKey) MoveOption {
+	return func(c *moveConfig) {
+		c.rerandomize = pubkey
+	}
+}
+
+// KeyOption configures GenerateKeyPair
+type KeyOption func(*keyConfig)
+
+type keyConfig struct {
+	primeRounds int
+	exactBits   bool
+	carmichael  bool
+	p, q        *big.Int
+	primesGiven bool //WithPrimes was used, even with a nil p or q
+	onProgress  func(stage string)
+	minGapBits  int
+	witnesses   []uint64
+	ctx         context.Context //nil unless generating through GenerateKeyPairContext
+}
+
+func newKeyConfig(opts []KeyOption) *keyConfig {
+	cfg := new(keyConfig)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithPrimeConfidence runs rounds extra Miller-Rabin tests (big.Int.ProbablyPrime)
+// on p & q and regenerates any candidate that fails them
+// candidates already pass ProbablyPrime(20), i.e. 20 Miller-Rabin rounds plus
+// a Baillie-PSW test, each extra round costs about one modular exponentiation of bits/2 bits
+func WithPrimeConfidence(rounds int) KeyOption {
+	return func(c *keyConfig) {
+		c.primeRounds = rounds
+	}
+}
+
+// WithFixedWitnesses runs an extra Miller-Rabin pass on p & q (and supplied primes)
+// with exactly these bases, so the primality verdict is reproducible and auditable
+// for a given candidate, see MillerRabin
+// it supplements, and doesn't replace, the randomized ProbablyPrime(20) check every candidate goes through
+func WithFixedWitnesses(witnesses []uint64) KeyOption {
+	return func(c *keyConfig) {
+		c.witnesses = append([]uint64(nil), witnesses...)
+	}
+}
+
+// WithExactBits makes GenerateKeyPair return a modulus of exactly bits bits
+// odd sizes split as ceil(bits/2) & floor(bits/2) bit primes, and a pair whose
+// product falls short is drawn again, so CiphertextSize & serialized lengths
+// only depend on the requested size
+func WithExactBits() KeyOption {
+	return func(c *keyConfig) {
+		c.exactBits = true
+	}
+}
+
+// WithPrimes makes GenerateKeyPair build the key from p & q instead of drawing them
+// they must be distinct primes, passing the WithPrimeConfidence rounds when given,
+// and n = p*q must have bits bits (or bits-1 like generated keys, unless WithExactBits)
+// a nil p or q makes GenerateKeyPair return an error wrapping ErrInvalidKey
+func WithPrimes(p, q *big.Int) KeyOption {
+	return func(c *keyConfig) {
+		c.primesGiven = true
+		c.p, c.q = nil, nil
+		if p != nil {
+			c.p = new(big.Int).Set(p)
+		}
+		if q != nil {
+			c.q = new(big.Int).Set(q)
+		}
+	}
+}
+
+// suppliedPrimes validates the primes given with WithPrimes for a bits long modulus
+// a nil p or q returns an error wrapping ErrInvalidKey
+func (c *keyConfig) suppliedPrimes(bits int) (*big.Int, *big.Int, error) {
+
+	if c.p == nil || c.q == nil {
+		return nil, nil, fmt.Errorf("%w: WithPrimes needs both p and q", ErrInvalidKey)
+	}
+
+	rounds := max(c.primeRounds, 20)
+	for _, x := range []*big.Int{c.p, c.q} {
+		if x.Cmp(big.NewInt(2)) <= 0 || !x.ProbablyPrime(rounds) || !MillerRabin(x, c.witnesses) {
+			return nil, nil, fmt.Errorf("%w: supplied value is not an odd prime", ErrInvalidPrimes)
+		}
+	}
+	if c.p.Cmp(c.q) == 0 {
+		return nil, nil, fmt.Errorf("%w: supplied primes are equal", ErrInvalidPrimes)
+	}
+	if !c.gapOK(c.p, c.q) {
+		return nil, nil, fmt.Errorf("%w: supplied primes are closer than 2^%d", ErrInvalidPrimes, c.minGapBits)
+	}
+
+	n := new(big.Int).Mul(c.p, c.q)
+	if got := n.BitLen(); got != bits && (c.exactBits || got != bits-1) {
+		return nil, nil, fmt.Errorf("%w: supplied primes give a %d bit modulus, want %d", ErrInvalidPrimes, got, bits)
+	}
+	//g = n+1 needs gcd(n, (p-1)(q-1)) = 1, always true for primes of equal size
+	phi := new(big.Int).Mul(new(big.Int).Sub(c.p, one), new(big.Int).Sub(c.q, one))
+	if new(big.Int).GCD(nil, nil, n, phi).Cmp(one) != 0 {
+		return nil, nil, fmt.Errorf("%w: gcd(pq, (p-1)(q-1)) != 1", ErrInvalidPrimes)
+	}
+
+	return c.p, c.q, nil
+}
+
+// WithCarmichaelLambda makes the private key use lambda = lcm(p-1, q-1) instead of (p-1)(q-1)
+// lambda is gcd(p-1, q-1) times smaller, at least 2, which shortens the decryption exponent a little
+// Decrypt needs no variant flag : m = L(c^lambda mod n^2) * mu mod n with
+// mu = L(g^lambda mod n^2)^-1 mod n holds for any multiple of lcm(p-1, q-1)
+func WithCarmichaelLambda() KeyOption {
+	return func(c *keyConfig) {
+		c.carmichael = true
+	}
+}
+
+// WithMinPrimeGap makes GenerateKeyPair redraw p & q until |p - q| >= 2^gapBits
+// primes close to each other let Fermat's method factor n = ((p+q)/2)^2 - ((p-q)/2)^2
+// quickly, FIPS 186-4 asks |p - q| > 2^(bits/2 - 100) for RSA and the same bound
+// suits Paillier moduli, random primes of bits/2 bits meet it with overwhelming probability
+// supplied primes (WithPrimes) that don't meet it are rejected
+func WithMinPrimeGap(gapBits int) KeyOption {
+	return func(c *keyConfig) {
+		c.minGapBits = gapBits
+	}
+}
+
+// gapOK reports whether |p - q| >= 2^minGapBits
+func (c *keyConfig) gapOK(p, q *big.Int) bool {
+	if c.minGapBits <= 0 {
+		return true
+	}
+	gap := new(big.Int).Sub(p, q)
+	return gap.Abs(gap).Cmp(new(big.Int).Lsh(one, uint(c.minGapBits))) >= 0
+}
+
+// WithProgress makes GenerateKeyPair call fn as it goes through the stages
+// "searching p", "p found", "searching q", "q found" and "computing key"
+// the prime stages repeat when a pair is drawn again (WithExactBits) and are skipped with WithPrimes
+// fn runs on the generating goroutine and should return quickly
+func WithProgress(fn func(stage string)) KeyOption {
+	return func(c *keyConfig) {
+		c.onProgress = fn
+	}
+}
+
+// progress reports stage to the WithProgress callback if any
+func (c *keyConfig) progress(stage string) {
+	if c.onProgress != nil {
+		c.onProgress(stage)
+	}
+}
+
+// done returns a non nil error once the generation context is done
+func (c *keyConfig) done() error {
+	if c.ctx == nil {
+		return nil
+	}
+	err := c.ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %v", ErrGenerationTimeout, err)
+	}
+	return err
+}
+
+// primeSizes returns the bit sizes of p & q for a bits long modulus
+func (c *keyConfig) primeSizes(bits int) (int, int) {
+	if c.exactBits {
+		return bits - bits/2, bits / 2
+	}
+	return bits / 2, bits / 2
+}
+
+// prime draws a bits long prime honouring the configured checks
+func (c *keyConfig) prime(random io.Reader, bits int) (*big.Int, error) {
+	for {
+		if err := c.done(); err != nil {
+			return nil, err
+		}
+		p, err := randPrime(random, bits)
+		if err != nil {
+			return nil, err
+		}
+		if (c.primeRounds <= 0 || p.ProbablyPrime(c.primeRounds)) && MillerRabin(p, c.witnesses) {
+			return p, nil
+		}
+	}
+}
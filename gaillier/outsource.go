@@ -0,0 +1,174 @@
+package gaillier
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+/*
+	Outsourced decryption
+
+	Any exponent e that decrypts every cipher is a multiple of lambda, and a multiple
+	of lambda factors n : the server can't be handed a working exponent. Instead the
+	server gets e1 = t*lambda - e2 with e2 a random delegationSecretBits bits secret
+	and t a random multiplier longer than n : it raises ciphers to e1 (most of the work),
+	the client finishes with the short c^e2 and divides L(c^(t*lambda)) by t mod n.
+	Knowing e1, finding a multiple of lambda means finding e2, about 2^128 steps with
+	baby step giant step for 256 bits. The multiplier keeps e1 far from lambda : with
+	t = 1, n+1-e1 = p+q+e2 gives p+q within 2^256 and Coppersmith's method factors n,
+	with t longer than n, e1 mod n = -(t*(p+q-1) + e2) mod n says nothing of p+q.
+
+	Each request carries the cipher c and a check cipher c' = c^a * (1 + b*n) for fresh
+	random a (delegationSecretBits bits) and b, decrypting to a*m + b. Both must come
+	back as valid powers with m' = a*m + b : a server altering a result has to alter the
+	other consistently, which needs a, a discrete log it can't take.
+	The client's cost is three short exponentiations instead of one of lambda's size,
+	the server's exponent is about twice as long as n.
+*/
+
+const delegationSecretBits = 256
+
+// DecryptDelegation is the client side of outsourced decryption for one key
+type DecryptDelegation struct {
+	privkey *PrivKey
+	secret  *big.Int //e2
+	tInv    *big.Int //t^-1 mod n
+
+	ServerExponent *big.Int //e1 = t*lambda - e2, to hand to the server once
+}
+
+// OutsourcedDecryption is one pending decryption : Bases go to the server, see OutsourceExp
+type OutsourcedDecryption struct {
+	Bases [][]byte
+
+	c, a, b *big.Int
+}
+
+// NewDecryptDelegation splits the key's lambda for outsourcing
+// lambda must be longer than the client secret, keys below about 300 bits return ErrInvalidKey
+func NewDecryptDelegation(privkey *PrivKey) (*DecryptDelegation, error) {
+
+	if err := privkey.Validate(); err != nil {
+		return nil, err
+	}
+	if privkey.L.BitLen() <= delegationSecretBits+32 {
+		return nil, fmt.Errorf("%w: key too small to outsource decryption", ErrInvalidKey)
+	}
+
+	secret, err := rand.Int(rand.Reader, new(big.Int).Lsh(one, delegationSecretBits))
+	if err != nil {
+		return nil, err
+	}
+	//t < n * 2^delegationSecretBits coprime to it, so t is invertible mod n
+	t, err := randomUnit(rand.Reader, new(big.Int).Lsh(privkey.N, delegationSecretBits))
+	if err != nil {
+		return nil, err
+	}
+	tInv, err := modInverse(t, privkey.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e1 := new(big.Int).Mul(t, privkey.L)
+
+	return &DecryptDelegation{
+		privkey:        privkey,
+		secret:         secret,
+		tInv:           tInv,
+		ServerExponent: e1.Sub(e1, secret),
+	}, nil
+}
+
+// OutsourceDecryptSetup prepares the decryption of cipher, send the returned Bases to the server
+func (d *DecryptDelegation) OutsourceDecryptSetup(cipher []byte) (*OutsourcedDecryption, error) {
+
+	pubkey := &d.privkey.PubKey
+	c := new(big.Int).SetBytes(cipher)
+	if !isUnit(c, pubkey.nsq()) {
+		return nil, ErrInvalidCiphertext
+	}
+
+	a, err := rand.Int(rand.Reader, new(big.Int).Lsh(one, delegationSecretBits))
+	if err != nil {
+		return nil, err
+	}
+	b, err := rand.Int(rand.Reader, pubkey.N)
+	if err != nil {
+		return nil, err
+	}
+
+	//c' = c^a * (1 + b*n)
+	check := new(big.Int).Mul(b, pubkey.N)
+	check.Add(check, one)
+	check.Mul(check, modExp(c, a, pubkey.nsq())).Mod(check, pubkey.nsq())
+
+	return &OutsourcedDecryption{Bases: [][]byte{c.Bytes(), check.Bytes()}, c: c, a: a, b: b}, nil
+}
+
+// OutsourceExp is the server side : it returns every base raised to exponent mod n^2
+func OutsourceExp(pubkey *PubKey, exponent *big.Int, bases [][]byte) ([][]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if exponent == nil || exponent.Sign() < 0 {
+		return nil, fmt.Errorf("%w: exponent must be non negative", ErrInvalidEncoding)
+	}
+
+	results := make([][]byte, len(bases))
+	for i, base := range bases {
+		results[i] = modExp(new(big.Int).SetBytes(base), exponent, pubkey.nsq()).Bytes()
+	}
+
+	return results, nil
+}
+
+// OutsourceDecryptFinish completes the decryption from the server results
+// results failing the check return an error wrapping ErrVerification
+func (d *DecryptDelegation) OutsourceDecryptFinish(o *OutsourcedDecryption, results [][]byte) ([]byte, error) {
+
+	if len(results) != len(o.Bases) {
+		return nil, fmt.Errorf("%w: got %d results for %d bases", ErrVerification, len(results), len(o.Bases))
+	}
+
+	m, err := d.finish(o.c, results[0])
+	if err != nil {
+		return nil, err
+	}
+	check, err := d.finish(new(big.Int).SetBytes(o.Bases[1]), results[1])
+	if err != nil {
+		return nil, err
+	}
+
+	//m' = a*m + b
+	want := new(big.Int).Mul(o.a, m)
+	want.Add(want, o.b).Mod(want, d.privkey.N)
+	if want.Cmp(check) != 0 {
+		return nil, fmt.Errorf("%w: check cipher decrypted inconsistently", ErrVerification)
+	}
+
+	return m.Bytes(), nil
+}
+
+// finish returns L(y * base^e2 mod n^2) * U * t^-1 mod n for the server result y = base^e1
+func (d *DecryptDelegation) finish(base *big.Int, result []byte) (*big.Int, error) {
+
+	k := d.privkey
+	y := new(big.Int).SetBytes(result)
+	if y.Cmp(k.nsq()) >= 0 {
+		return nil, fmt.Errorf("%w: result out of range", ErrVerification)
+	}
+
+	x := modExp(base, d.secret, k.nsq())
+	x.Mul(x, y).Mod(x, k.nsq())
+
+	//x = base^(t*lambda) must be 1 mod n
+	l, r := new(big.Int).QuoRem(new(big.Int).Sub(x, one), k.N, new(big.Int))
+	if r.Sign() != 0 {
+		return nil, fmt.Errorf("%w: result is not a valid power", ErrVerification)
+	}
+	l.Mul(l, k.U).Mod(l, k.N)
+
+	return l.Mul(l, d.tInv).Mod(l, k.N), nil
+}
@@ -0,0 +1,67 @@
+package gaillier
+
+import "fmt"
+
+/*
+	Public rearrangements of cipher vectors
+
+	Moving ciphers around needs no key, but an output cipher equal to an input one
+	reveals where it came from, re-randomizing on move (ReRandomizeOnMove) hides it.
+*/
+
+// Permute returns out with out[i] = ciphers[perm[i]]
+// perm must be a permutation of 0 .. len(ciphers)-1, otherwise an error wrapping ErrDimensionMismatch is returned
+func Permute(ciphers [][]byte, perm []int, opts ...MoveOption) ([][]byte, error) {
+
+	if len(perm) != len(ciphers) {
+		return nil, fmt.Errorf("%w: permutation of %d indices for %d ciphers", ErrDimensionMismatch, len(perm), len(ciphers))
+	}
+
+	used := make([]bool, len(ciphers))
+	for _, j := range perm {
+		if j < 0 || j >= len(ciphers) || used[j] {
+			return nil, fmt.Errorf("%w: %v is not a permutation", ErrDimensionMismatch, perm)
+		}
+		used[j] = true
+	}
+
+	return move(ciphers, perm, opts)
+}
+
+// SelectIndices returns out with out[i] = ciphers[idx[i]]
+// indices may repeat, an index out of range returns an error wrapping ErrDimensionMismatch
+func SelectIndices(ciphers [][]byte, idx []int, opts ...MoveOption) ([][]byte, error) {
+
+	for _, j := range idx {
+		if j < 0 || j >= len(ciphers) {
+			return nil, fmt.Errorf("%w: index %d out of range [0, %d)", ErrDimensionMismatch, j, len(ciphers))
+		}
+	}
+
+	return move(ciphers, idx, opts)
+}
+
+// move gathers ciphers[idx[i]] into fresh slices, re-randomized when asked to
+func move(ciphers [][]byte, idx []int, opts []MoveOption) ([][]byte, error) {
+
+	cfg := new(moveConfig)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	out := make([][]byte, len(idx))
+	for i, j := range idx {
+		out[i] = ciphers[j]
+	}
+
+	if cfg.rerandomize != nil {
+		//each output gets its own r, so repeated selections don't match each other either
+		return ReRandomizeBatch(cfg.rerandomize, out)
+	}
+
+	for i := range out {
+		out[i] = append([]byte(nil), out[i]...)
+	}
+
+	return out, nil
+}
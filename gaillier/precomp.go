@@ -0,0 +1,117 @@
+package gaillier
+
+import "math/big"
+
+/*
+	Fixed base exponentiation for g
+
+	g is fixed per key so g^m can use a precomputed fixed-window table
+	table[i][d] = g^(d * 16^i) mod n^2 for every 4 bit window i of an exponent < n.
+	g^m is then a product of one table entry per non zero window, no squaring needed.
+
+	For the standard g = n + 1 none of this is needed since (1 + n)^m = 1 + m*n mod n^2.
+	The table is opt-in : PrecomputeG builds it into the key's cache, which copies of
+	the key share. Without a table (or once G changed) g^m is a plain modExp.
+*/
+
+const gWindowBits = 4
+
+type gTable struct {
+	g, nsq  *big.Int //the values the table was built for
+	windows [][1<<gWindowBits - 1]*big.Int
+}
+
+// PrecomputeG builds the fixed-window table of powers of g used by Encrypt & AddConstant,
+// without it they compute g^m with a full exponentiation
+// it costs about 4 * N.BitLen() multiplications and 15 * N.BitLen() / 4 values mod n^2 of memory
+// keys with the standard g = n+1 don't need a table and skip it
+// call it before sharing the key between goroutines, a key built as a struct literal
+// gets its cache here, copies made afterwards (Public, PrivKey.PubKey) share the table
+func (p *PubKey) PrecomputeG() {
+	if p.isStandardG() {
+		return
+	}
+	if p.cache == nil {
+		p.cache = new(keyCache)
+	}
+	if p.gTable() == nil {
+		p.cache.g.Store(p.buildGTable())
+	}
+}
+
+// gTable returns the cached table of p's current G, nil when PrecomputeG wasn't called
+// or the table was built for another G (the key was modified)
+func (p *PubKey) gTable() *gTable {
+	if p.cache == nil {
+		return nil
+	}
+	if t := p.cache.g.Load(); t != nil && t.g.Cmp(p.G) == 0 && t.nsq.Cmp(p.nsq()) == 0 {
+		return t
+	}
+	return nil
+}
+
+// buildGTable computes the fixed-window table of p's G
+func (p *PubKey) buildGTable() *gTable {
+
+	nw := (p.N.BitLen() + gWindowBits - 1) / gWindowBits
+	t := &gTable{
+		g:       new(big.Int).Set(p.G),
+		nsq:     new(big.Int).Set(p.nsq()),
+		windows: make([][1<<gWindowBits - 1]*big.Int, nw),
+	}
+
+	base := new(big.Int).Set(p.G)
+	for i := range t.windows {
+		t.windows[i][0] = base
+		for d := 1; d < len(t.windows[i]); d++ {
+			t.windows[i][d] = new(big.Int).Mul(t.windows[i][d-1], base)
+			t.windows[i][d].Mod(t.windows[i][d], p.nsq())
+		}
+		//next base = g^(16^(i+1))
+		base = new(big.Int).Mul(t.windows[i][len(t.windows[i])-1], base)
+		base.Mod(base, p.nsq())
+	}
+
+	return t
+}
+
+// gExp returns g^m mod n^2 using the cheapest available method
+func (p *PubKey) gExp(m *big.Int) *big.Int {
+
+	//(1 + n)^m = 1 + m*n mod n^2
+	if p.isStandardG() {
+		res := new(big.Int).Mul(m, p.N)
+		res.Add(res, one)
+		return res.Mod(res, p.nsq())
+	}
+
+	t := p.gTable()
+	if t == nil || m.Sign() < 0 || m.BitLen() > len(t.windows)*gWindowBits {
+		return modExp(p.G, m, p.nsq())
+	}
+
+	res := big.NewInt(1)
+	for i := range t.windows {
+		d := window(m, i)
+		if d != 0 {
+			res.Mul(res, t.windows[i][d-1])
+			res.Mod(res, p.nsq())
+		}
+	}
+
+	return res
+}
+
+func (p *PubKey) isStandardG() bool {
+	return p.G.Cmp(new(big.Int).Add(p.N, one)) == 0
+}
+
+// window returns the i-th 4 bit window of m
+func window(m *big.Int, i int) uint {
+	var d uint
+	for b := gWindowBits - 1; b >= 0; b-- {
+		d = d<<1 | m.Bit(i*gWindowBits+b)
+	}
+	return d
+}
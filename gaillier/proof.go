@@ -0,0 +1,231 @@
+package gaillier
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+/*
+	Proofs of n-th residuosity
+
+	u is an encryption of zero exactly when u = w^n mod n^2 for some unit w.
+	Knowing w, the prover shows it without revealing it (sigma protocol made
+	non interactive with Fiat-Shamir) :
+	1. pick a random unit rho, send a = rho^n mod n^2
+	   rho is drawn from SHA-256(w, statement, 32 random bytes) : a broken or
+	   repeating random source can't reuse rho for two different statements
+	   (which would reveal w), and a good one keeps rho unpredictable
+	2. challenge e = SHA-256(label, n, statement, a) truncated to 128 bits
+	3. send z = rho * w^e mod n
+	The verifier checks z^n = a * u^e mod n^2. Challenges are shorter than the
+	primes of any usable key, so a cheating prover succeeds with probability 2^-128.
+	The statement ciphers are hashed into e, a proof can't be moved to other ciphers.
+*/
+
+// challengeBytes is the Fiat-Shamir challenge size
+const challengeBytes = 16
+
+// EqualityProof proves two ciphers encrypt the same plaintext
+type EqualityProof struct {
+	A *big.Int
+	Z *big.Int
+}
+
+// ProveEqualPlaintext proves c1 and c2 encrypt the same plaintext given the r1, r2 they
+// were encrypted with (see EncryptWithRandomness), the plaintext is not revealed
+// it returns an error wrapping ErrInvalidRandomness when c1 / c2 isn't (r1 / r2)^n
+func ProveEqualPlaintext(pubkey *PubKey, c1, c2 []byte, r1, r2 *big.Int) (*EqualityProof, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if !isUnit(r1, pubkey.N) || !isUnit(r2, pubkey.N) {
+		return nil, ErrInvalidRandomness
+	}
+
+	u, err := cipherRatio(pubkey, c1, c2)
+	if err != nil {
+		return nil, err
+	}
+	r2Inv, err := modInverse(r2, pubkey.N)
+	if err != nil {
+		return nil, err
+	}
+	w := r2Inv.Mul(r2Inv, r1)
+	w.Mod(w, pubkey.N)
+
+	a, z, err := proveNthRoot(pubkey, u, w, "equal plaintext", c1, c2)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EqualityProof{A: a, Z: z}, nil
+}
+
+// VerifyEqualPlaintext checks a proof made by ProveEqualPlaintext for c1 and c2
+func VerifyEqualPlaintext(pubkey *PubKey, c1, c2 []byte, proof *EqualityProof) bool {
+
+	if proof == nil || pubkey.Validate() != nil {
+		return false
+	}
+	u, err := cipherRatio(pubkey, c1, c2)
+	if err != nil {
+		return false
+	}
+
+	return verifyNthRoot(pubkey, u, proof.A, proof.Z, "equal plaintext", c1, c2)
+}
+
+// cipherRatio returns c1 / c2 mod n^2, both must be units of Z/n^2Z
+func cipherRatio(pubkey *PubKey, c1, c2 []byte) (*big.Int, error) {
+
+	x, y := new(big.Int).SetBytes(c1), new(big.Int).SetBytes(c2)
+	if !isUnit(x, pubkey.nsq()) || !isUnit(y, pubkey.nsq()) {
+		return nil, fmt.Errorf("%w: cipher is not a unit mod n^2", ErrInvalidEncoding)
+	}
+	yInv, err := modInverse(y, pubkey.nsq())
+	if err != nil {
+		return nil, err
+	}
+
+	return yInv.Mod(yInv.Mul(yInv, x), pubkey.nsq()), nil
+}
+
+// proveNthRoot proves knowledge of w with u = w^n mod n^2, bound to label and ciphers
+func proveNthRoot(pubkey *PubKey, u, w *big.Int, label string, ciphers ...[]byte) (*big.Int, *big.Int, error) {
+
+	if modExp(w, pubkey.N, pubkey.nsq()).Cmp(u) != 0 {
+		return nil, nil, fmt.Errorf("%w: randomness doesn't match the ciphers", ErrInvalidRandomness)
+	}
+
+	rho, err := proofNonce(pubkey, w, label, ciphers)
+	if err != nil {
+		return nil, nil, err
+	}
+	a := modExp(rho, pubkey.N, pubkey.nsq())
+	e := challenge(pubkey, a, label, ciphers)
+
+	//z = rho * w^e mod n
+	z := new(big.Int).Exp(w, e, pubkey.N)
+	z.Mod(z.Mul(z, rho), pubkey.N)
+
+	return a, z, nil
+}
+
+// proofNonce derives the hedged nonce rho of proveNthRoot
+func proofNonce(pubkey *PubKey, w *big.Int, label string, ciphers [][]byte) (*big.Int, error) {
+
+	fresh := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, fresh); err != nil {
+		return nil, err
+	}
+
+	seed := proofHash(pubkey, "nonce "+label, ciphers, w.Bytes(), fresh)
+
+	return randomUnit(newSeedReader(seed), pubkey.N)
+}
+
+// verifyNthRoot checks z^n = a * u^e mod n^2
+func verifyNthRoot(pubkey *PubKey, u, a, z *big.Int, label string, ciphers ...[]byte) bool {
+
+	if a == nil || z == nil || !isUnit(a, pubkey.nsq()) || !isUnit(z, pubkey.N) {
+		return false
+	}
+	e := challenge(pubkey, a, label, ciphers)
+
+	rhs := modExp(u, e, pubkey.nsq())
+	rhs.Mod(rhs.Mul(rhs, a), pubkey.nsq())
+
+	return modExp(z, pubkey.N, pubkey.nsq()).Cmp(rhs) == 0
+}
+
+// challenge is the hash of the label, the key, the statement ciphers and a, truncated to challengeBytes
+func challenge(pubkey *PubKey, a *big.Int, label string, ciphers [][]byte) *big.Int {
+	return new(big.Int).SetBytes(proofHash(pubkey, label, ciphers, a.Bytes())[:challengeBytes])
+}
+
+// proofHash hashes the label, n, g, the statement ciphers and tail, length prefixed
+func proofHash(pubkey *PubKey, label string, ciphers [][]byte, tail ...[]byte) []byte {
+
+	h := sha256.New()
+	write := func(b []byte) {
+		var l [8]byte
+		binary.BigEndian.PutUint64(l[:], uint64(len(b)))
+		h.Write(l[:])
+		h.Write(b)
+	}
+	write([]byte("gomorph " + label))
+	write(pubkey.N.Bytes())
+	write(pubkey.G.Bytes())
+	for _, c := range ciphers {
+		write(c)
+	}
+	for _, b := range tail {
+		write(b)
+	}
+
+	return h.Sum(nil)
+}
+
+// RecomputeCiphertext returns g^m * r^n mod n^2, the cipher EncryptWithRandomness gives for m & r
+// m is taken mod n, no private key is needed
+func (p *PubKey) RecomputeCiphertext(m, r *big.Int) []byte {
+	return encrypt(p, new(big.Int).Mod(m, p.N), r).Bytes()
+}
+
+// VerifyOpening reports whether (m, r) opens cipher : 0 <= m < n, r is a unit of Z/nZ
+// and cipher = g^m * r^n mod n^2
+func (p *PubKey) VerifyOpening(cipher []byte, m, r *big.Int) bool {
+
+	if p.Validate() != nil || m == nil || m.Sign() < 0 || m.Cmp(p.N) >= 0 || !isUnit(r, p.N) {
+		return false
+	}
+
+	return encrypt(p, m, r).Cmp(new(big.Int).SetBytes(cipher)) == 0
+}
+
+// ReRandProof proves a cipher is a re-randomization of another, newC / oldC = r^n
+type ReRandProof struct {
+	A *big.Int
+	Z *big.Int
+}
+
+// ReRandomizeWithProof re-randomizes cipher like ReRandomize and proves the result
+// encrypts the same plaintext : newC / cipher is an encryption of zero
+// the factor r stays secret, the proof only shows it exists
+func ReRandomizeWithProof(pubkey *PubKey, cipher []byte) ([]byte, *ReRandProof, error) {
+
+	newC, r, err := ReRandomizeWithFactor(pubkey, cipher)
+	if err != nil {
+		return nil, nil, err
+	}
+	u, err := cipherRatio(pubkey, newC, cipher)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	a, z, err := proveNthRoot(pubkey, u, r, "rerandomize", cipher, newC)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newC, &ReRandProof{A: a, Z: z}, nil
+}
+
+// VerifyReRand checks a proof made by ReRandomizeWithProof that newC re-randomizes oldC
+func VerifyReRand(pubkey *PubKey, oldC, newC []byte, proof *ReRandProof) bool {
+
+	if proof == nil || pubkey.Validate() != nil {
+		return false
+	}
+	u, err := cipherRatio(pubkey, newC, oldC)
+	if err != nil {
+		return false
+	}
+
+	return verifyNthRoot(pubkey, u, proof.A, proof.Z, "rerandomize", oldC, newC)
+}
@@ -0,0 +1,168 @@
+package gaillier
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+/*
+	Passphrase protected private keys
+
+	The gob encoded private key is sealed with AES-256-GCM under a key derived
+	from the passphrase. The container is JSON and records the KDF name and its
+	parameters next to the salt, so stronger settings can be adopted later while
+	old containers still open.
+	The KDF is PBKDF2-HMAC-SHA256 (RFC 8018), written here on crypto/hmac since
+	crypto/pbkdf2 needs Go 1.24, scrypt & argon2 would pull in golang.org/x/crypto.
+	The KDF parameters are bound to the ciphertext as GCM additional data.
+*/
+
+const (
+	kdfPBKDF2SHA256    = "pbkdf2-sha256"
+	defaultKDFRounds   = 600000
+	protectedKeyFormat = 1
+)
+
+// maxKDFRounds caps the iterations a container may ask for : the count comes from
+// untrusted JSON and a huge one would keep ParseEncryptedPrivKey busy forever
+const maxKDFRounds = 10 * defaultKDFRounds
+
+// protectedKey is the serialized container
+type protectedKey struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Sealed     []byte `json:"sealed"`
+}
+
+// MarshalEncryptedPrivKey serializes k encrypted under a key derived from passphrase
+func MarshalEncryptedPrivKey(k *PrivKey, passphrase []byte) ([]byte, error) {
+
+	if err := k.Validate(); err != nil {
+		return nil, err
+	}
+
+	w := new(bytes.Buffer)
+	if err := gob.NewEncoder(w).Encode(k); err != nil {
+		return nil, err
+	}
+
+	pk := &protectedKey{
+		Version:    protectedKeyFormat,
+		KDF:        kdfPBKDF2SHA256,
+		Iterations: defaultKDFRounds,
+		Salt:       make([]byte, 16),
+	}
+	if _, err := rand.Read(pk.Salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := pk.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	pk.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(pk.Nonce); err != nil {
+		return nil, err
+	}
+	pk.Sealed = aead.Seal(nil, pk.Nonce, w.Bytes(), pk.additionalData())
+
+	return json.Marshal(pk)
+}
+
+// ParseEncryptedPrivKey opens a container made by MarshalEncryptedPrivKey
+// a wrong passphrase or altered container returns an error wrapping ErrTagMismatch
+func ParseEncryptedPrivKey(data, passphrase []byte) (*PrivKey, error) {
+
+	pk := new(protectedKey)
+	if err := json.Unmarshal(data, pk); err != nil {
+		return nil, fmt.Errorf("%w: key container: %v", ErrInvalidEncoding, err)
+	}
+	if pk.Version != protectedKeyFormat {
+		return nil, fmt.Errorf("%w: key container version %d", ErrInvalidEncoding, pk.Version)
+	}
+
+	aead, err := pk.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(pk.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: key container nonce", ErrInvalidEncoding)
+	}
+	plain, err := aead.Open(nil, pk.Nonce, pk.Sealed, pk.additionalData())
+	if err != nil {
+		return nil, fmt.Errorf("%w: wrong passphrase or altered key container", ErrTagMismatch)
+	}
+
+	k := new(PrivKey)
+	if err := gob.NewDecoder(bytes.NewReader(plain)).Decode(k); err != nil {
+		return nil, fmt.Errorf("%w: key: %v", ErrInvalidEncoding, err)
+	}
+	if err := k.Validate(); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// aead derives the container key from passphrase and returns the AES-GCM instance
+func (pk *protectedKey) aead(passphrase []byte) (cipher.AEAD, error) {
+
+	if pk.KDF != kdfPBKDF2SHA256 {
+		return nil, fmt.Errorf("%w: unknown KDF %q", ErrInvalidEncoding, pk.KDF)
+	}
+	if pk.Iterations <= 0 || pk.Iterations > maxKDFRounds || len(pk.Salt) == 0 {
+		return nil, fmt.Errorf("%w: invalid KDF parameters", ErrInvalidEncoding)
+	}
+
+	block, err := aes.NewCipher(pbkdf2SHA256(passphrase, pk.Salt, pk.Iterations, 32))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// additionalData binds the format and KDF parameters to the sealed key
+func (pk *protectedKey) additionalData() []byte {
+	return fmt.Appendf(nil, "%d:%s:%d", pk.Version, pk.KDF, pk.Iterations)
+}
+
+// pbkdf2SHA256 is PBKDF2 (RFC 8018 section 5.2) with HMAC-SHA256 as the PRF
+// T_i = U_1 ^ ... ^ U_c with U_1 = PRF(P, S || INT(i)) and U_j = PRF(P, U_j-1)
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+
+	prf := hmac.New(sha256.New, password)
+	dk := make([]byte, 0, keyLen+sha256.Size)
+	u := make([]byte, sha256.Size)
+	t := make([]byte, sha256.Size)
+
+	for block := uint32(1); len(dk) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(binary.BigEndian.AppendUint32(nil, block))
+		u = prf.Sum(u[:0])
+		copy(t, u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		dk = append(dk, t...)
+	}
+
+	return dk[:keyLen]
+}
@@ -0,0 +1,132 @@
+package gaillier
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+/*
+	Provenance
+
+	A ProvenanceCiphertext keeps with a derived cipher the op that produced it,
+	the fingerprints of its input ciphers and the plaintext constant if any, so
+	stored results can be traced back and checked later : every op name is one
+	transcript OpKind, TranscriptOp gives the step and VerifyProvenance replays it
+	with ReplayTranscript over the input ciphers.
+	It marshals to JSON as is.
+*/
+
+// ProvenanceCiphertext is a cipher with the record of how it was computed
+type ProvenanceCiphertext struct {
+	KeyFingerprint string   `json:"key"`
+	Op             string   `json:"op"`
+	Inputs         []string `json:"inputs"`
+	Constant       []byte   `json:"constant,omitempty"`
+	Cipher         []byte   `json:"cipher"`
+}
+
+// provenanceOps maps the recorded op names to their transcript kinds
+var provenanceOps = map[string]OpKind{
+	"add":          OpAdd,
+	"sub":          OpSub,
+	"mul":          OpMul,
+	"add constant": OpAddConstant,
+}
+
+// CiphertextFingerprint identifies a cipher by the hex SHA-256 of its bytes
+func CiphertextFingerprint(cipher []byte) string {
+	h := sha256.Sum256(cipher)
+	return hex.EncodeToString(h[:])
+}
+
+// AddP is Add recording the "add" op and both inputs
+func AddP(pubkey *PubKey, c1, c2 []byte) *ProvenanceCiphertext {
+	return newProvenance(pubkey, "add", Add(pubkey, c1, c2), nil, c1, c2)
+}
+
+// SubP is Sub recording the "sub" op and both inputs
+func SubP(pubkey *PubKey, c1, c2 []byte) *ProvenanceCiphertext {
+	return newProvenance(pubkey, "sub", Sub(pubkey, c1, c2), nil, c1, c2)
+}
+
+// MulP is Mul recording the "mul" op, the input and the constant
+func MulP(pubkey *PubKey, cipher, constant []byte) *ProvenanceCiphertext {
+	return newProvenance(pubkey, "mul", Mul(pubkey, cipher, constant), constant, cipher)
+}
+
+// AddConstantP is AddConstant recording the "add constant" op, the input and the constant
+func AddConstantP(pubkey *PubKey, cipher, constant []byte) *ProvenanceCiphertext {
+	return newProvenance(pubkey, "add constant", AddConstant(pubkey, cipher, constant), constant, cipher)
+}
+
+func newProvenance(pubkey *PubKey, op string, cipher, constant []byte, inputs ...[]byte) *ProvenanceCiphertext {
+
+	p := &ProvenanceCiphertext{
+		KeyFingerprint: pubkey.Fingerprint(),
+		Op:             op,
+		Inputs:         make([]string, len(inputs)),
+		Cipher:         cipher,
+	}
+	if constant != nil {
+		p.Constant = append([]byte(nil), constant...)
+	}
+	for i, in := range inputs {
+		p.Inputs[i] = CiphertextFingerprint(in)
+	}
+
+	return p
+}
+
+// TranscriptOp returns the transcript step recorded by p, its operands are registers
+// 0 and 1 : the inputs in the order of p.Inputs
+// unknown op names return an error wrapping ErrInvalidTranscript
+func (p *ProvenanceCiphertext) TranscriptOp() (Op, error) {
+
+	kind, ok := provenanceOps[p.Op]
+	if !ok {
+		return Op{}, fmt.Errorf("%w: unknown provenance op %q", ErrInvalidTranscript, p.Op)
+	}
+
+	op := Op{Kind: kind, A: 0, Constant: p.Constant}
+	if kind == OpAdd || kind == OpSub {
+		op.B = 1
+	}
+	return op, nil
+}
+
+// VerifyProvenance checks that p was computed under pubkey from inputs : their fingerprints
+// must be the recorded ones and replaying the op over them must give p.Cipher
+// a record that doesn't match returns an error wrapping ErrVerification
+func VerifyProvenance(pubkey *PubKey, p *ProvenanceCiphertext, inputs ...[]byte) error {
+
+	if p == nil {
+		return fmt.Errorf("%w: nil provenance record", ErrInvalidEncoding)
+	}
+	if err := CheckFingerprint(pubkey, p.KeyFingerprint); err != nil {
+		return err
+	}
+	if len(inputs) != len(p.Inputs) {
+		return fmt.Errorf("%w: %d inputs for %d recorded", ErrVerification, len(inputs), len(p.Inputs))
+	}
+	for i, in := range inputs {
+		if CiphertextFingerprint(in) != p.Inputs[i] {
+			return fmt.Errorf("%w: input %d has another fingerprint than recorded", ErrVerification, i)
+		}
+	}
+
+	op, err := p.TranscriptOp()
+	if err != nil {
+		return err
+	}
+	c, err := ReplayTranscript(pubkey, inputs, []Op{op})
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(c, p.Cipher) {
+		return fmt.Errorf("%w: replaying %q doesn't give the recorded cipher", ErrVerification, p.Op)
+	}
+
+	return nil
+}
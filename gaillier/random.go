@@ -0,0 +1,275 @@
+package gaillier
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+)
+
+/*
+	Randomness helpers
+
+	crypto/rand.Prime ignores its io.Reader since Go 1.26 (unless GODEBUG=cryptocustomrand=1)
+	so key generation runs its own prime search to honour the reader it is given.
+*/
+
+// RandomSource wraps an entropy source that may occasionally fail or short-read
+// (HSM backed readers ...) and retries failed reads before giving up
+// it is safe for concurrent use if the wrapped reader is
+type RandomSource struct {
+	Reader  io.Reader
+	Retries int           //extra attempts allowed per Read call
+	Backoff time.Duration //pause between attempts
+}
+
+// NewRandomSource wraps r allowing retries failed attempts per Read
+func NewRandomSource(r io.Reader, retries int) *RandomSource {
+	return &RandomSource{Reader: r, Retries: retries}
+}
+
+// Read fills p entirely, short reads are continued and errors (or reads
+// making no progress) are retried up to Retries times
+func (s *RandomSource) Read(p []byte) (int, error) {
+
+	n, failures := 0, 0
+	for n < len(p) {
+		k, err := s.Reader.Read(p[n:])
+		n += k
+		if err == nil && k > 0 {
+			continue
+		}
+		if n == len(p) {
+			break
+		}
+		failures++
+		if failures > s.Retries {
+			if err == nil {
+				err = io.ErrNoProgress
+			}
+			return n, fmt.Errorf("%w: %v", ErrRandomSource, err)
+		}
+		time.Sleep(s.Backoff)
+	}
+
+	return n, nil
+}
+
+// Health reads a small probe and reports an error if the source fails
+// or returns a constant output (a stuck source)
+func (s *RandomSource) Health() error {
+
+	probe := make([]byte, 32)
+	if _, err := s.Read(probe); err != nil {
+		return err
+	}
+	for _, b := range probe[1:] {
+		if b != probe[0] {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: constant output", ErrRandomSource)
+}
+
+// ProbeEntropy times reading bytes bytes from r and returns the throughput in bytes per second
+// a key of bits bits reads at least bits/8 bytes, usually many times more as most candidates
+// are not prime, a service can warn or lower the size when the source is too slow
+func ProbeEntropy(r io.Reader, bytes int) (float64, error) {
+
+	if bytes < 1 {
+		return 0, fmt.Errorf("%w: probe of %d bytes", ErrRandomSource, bytes)
+	}
+
+	buf := make([]byte, bytes)
+	start := time.Now()
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrRandomSource, err)
+	}
+
+	return float64(bytes) / time.Since(start).Seconds(), nil
+}
+
+// randPrime returns a prime of exactly bits bits drawn from random
+// it follows the same candidate construction as crypto/rand.Prime
+// (top two bits set so the product of two such primes is never one bit short)
+func randPrime(random io.Reader, bits int) (*big.Int, error) {
+
+	if bits < 2 {
+		return nil, ErrInvalidPrimes
+	}
+
+	b := uint(bits % 8)
+	if b == 0 {
+		b = 8
+	}
+
+	buf := make([]byte, (bits+7)/8)
+	p := new(big.Int)
+
+	for {
+		if _, err := io.ReadFull(random, buf); err != nil {
+			return nil, err
+		}
+
+		//keep the candidate at most bits long
+		buf[0] &= uint8(int(1<<b) - 1)
+		//set the two most significant bits
+		if b >= 2 {
+			buf[0] |= 3 << (b - 2)
+		} else {
+			buf[0] |= 1
+			if len(buf) > 1 {
+				buf[1] |= 0x80
+			}
+		}
+		//make it odd
+		buf[len(buf)-1] |= 1
+
+		p.SetBytes(buf)
+		if p.ProbablyPrime(20) {
+			return p, nil
+		}
+	}
+}
+
+// randomUnit returns a uniformly random unit of Z/nZ (0 < r < n, gcd(r, n) = 1)
+// using rejection sampling over n.BitLen() bit candidates
+func randomUnit(random io.Reader, n *big.Int) (*big.Int, error) {
+
+	bits := n.BitLen()
+	buf := make([]byte, (bits+7)/8)
+	mask := uint8(int(1<<uint(bits%8)) - 1)
+	if bits%8 == 0 {
+		mask = 0xff
+	}
+	r := new(big.Int)
+
+	for {
+		if _, err := io.ReadFull(random, buf); err != nil {
+			return nil, err
+		}
+		buf[0] &= mask
+
+		r.SetBytes(buf)
+		if isUnit(r, n) {
+			return r, nil
+		}
+	}
+}
+
+// RandomnessBitsPerEncrypt returns how many random bits one candidate r costs Encrypt,
+// n.BitLen() rounded up to whole bytes
+// candidates at or above n (or sharing a factor with n) are rejected and drawn again,
+// as n >= 2^(bits-1) at most half of them are, so an encryption draws this many bits
+// less than twice on average
+func (p *PubKey) RandomnessBitsPerEncrypt() int {
+	return 8 * ((p.N.BitLen() + 7) / 8)
+}
+
+// CountingReader counts the bytes read through it
+type CountingReader struct {
+	R io.Reader
+	N int64
+}
+
+func (c *CountingReader) Read(b []byte) (int, error) {
+	n, err := c.R.Read(b)
+	c.N += int64(n)
+	return n, err
+}
+
+// MeasureEncryptRandomness encrypts a sample message with random and returns the bytes it read
+func (p *PubKey) MeasureEncryptRandomness(random io.Reader) (int64, error) {
+	cr := &CountingReader{R: random}
+	_, err := EncryptWithReader(cr, p, one.Bytes())
+	return cr.N, err
+}
+
+// MillerRabin reports whether n passes a strong probable prime test to every base in witnesses
+// bases are taken mod n and the trivial ones (0, 1 and n-1) skipped, an even n > 2 always fails
+// composites passing it exist for any fixed set (2047 = 23*89 passes base 2) : the set decides
+// the strength, the 13 primes 2 .. 41 as bases are exact for every n below 3.3*10^24
+func MillerRabin(n *big.Int, witnesses []uint64) bool {
+
+	two := big.NewInt(2)
+	if n.Cmp(two) < 0 {
+		return false
+	}
+	if n.Bit(0) == 0 {
+		return n.Cmp(two) == 0
+	}
+
+	//n-1 = d * 2^s with d odd
+	nMin := new(big.Int).Sub(n, one)
+	s := nMin.TrailingZeroBits()
+	d := new(big.Int).Rsh(nMin, s)
+
+	a := new(big.Int)
+	for _, w := range witnesses {
+		a.SetUint64(w).Mod(a, n)
+		if a.Sign() == 0 || a.Cmp(one) == 0 || a.Cmp(nMin) == 0 {
+			continue
+		}
+		x := new(big.Int).Exp(a, d, n)
+		if x.Cmp(one) == 0 || x.Cmp(nMin) == 0 {
+			continue
+		}
+		composite := true
+		for i := uint(1); i < s; i++ {
+			x.Mul(x, x).Mod(x, n)
+			if x.Cmp(nMin) == 0 {
+				composite = false
+				break
+			}
+		}
+		if composite {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isUnit reports whether 0 < r < n and gcd(r, n) = 1
+func isUnit(r, n *big.Int) bool {
+	if r == nil || r.Sign() <= 0 || r.Cmp(n) >= 0 {
+		return false
+	}
+	return new(big.Int).GCD(nil, nil, r, n).Cmp(one) == 0
+}
+
+// seedReader is a deterministic byte stream SHA-256(seed || counter)
+// it is NOT a CSPRNG replacement, only used to derive reproducible test vectors
+type seedReader struct {
+	seed    []byte
+	counter uint64
+	block   []byte
+}
+
+func newSeedReader(seed []byte) *seedReader {
+	return &seedReader{seed: append([]byte(nil), seed...)}
+}
+
+func (s *seedReader) Read(p []byte) (int, error) {
+
+	n := 0
+	for n < len(p) {
+		if len(s.block) == 0 {
+			var c [8]byte
+			binary.BigEndian.PutUint64(c[:], s.counter)
+			s.counter++
+			h := sha256.New()
+			h.Write(s.seed)
+			h.Write(c[:])
+			s.block = h.Sum(nil)
+		}
+		k := copy(p[n:], s.block)
+		s.block = s.block[k:]
+		n += k
+	}
+
+	return n, nil
+}
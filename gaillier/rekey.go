@@ -0,0 +1,26 @@
+package gaillier
+
+import "fmt"
+
+// Upsize moves cipher from oldPriv's key to newPub by decrypting and encrypting again
+// it is meant to grow the plaintext space, a plaintext that doesn't fit the new n
+// returns an error wrapping ErrLongMessage
+// the plaintext goes through the caller's memory, run it where oldPriv already lives
+func Upsize(oldPriv *PrivKey, newPub *PubKey, cipher []byte) ([]byte, error) {
+
+	if err := newPub.Validate(); err != nil {
+		return nil, err
+	}
+
+	m, err := Decrypt(oldPriv, cipher)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(m)
+
+	if !newPub.CanEncrypt(m) {
+		return nil, fmt.Errorf("%w: plaintext doesn't fit the %d bit key", ErrLongMessage, newPub.N.BitLen())
+	}
+
+	return Encrypt(newPub, m)
+}
@@ -0,0 +1,69 @@
+package gaillier
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+/*
+	ReplayGuard detects ciphertexts submitted more than once
+
+	It only catches naive replays : anyone can re-randomize a cipher
+	(multiply it by r^n mod n^2) to get different bytes that decrypt to the
+	same plaintext, which bypasses any byte level detection.
+*/
+
+// ReplayGuard remembers the SHA-256 fingerprints of the last ciphers it saw
+// it is safe for concurrent use
+type ReplayGuard struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List //most recently seen at the front
+	seen     map[[sha256.Size]byte]*list.Element
+}
+
+// NewReplayGuard returns a guard remembering up to capacity fingerprints (at least 1)
+// once full the least recently seen fingerprint is evicted
+func NewReplayGuard(capacity int) *ReplayGuard {
+
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &ReplayGuard{
+		capacity: capacity,
+		order:    list.New(),
+		seen:     make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+// Seen reports whether cipher was already seen and records it
+func (g *ReplayGuard) Seen(cipher []byte) bool {
+
+	fp := sha256.Sum256(cipher)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if e, ok := g.seen[fp]; ok {
+		g.order.MoveToFront(e)
+		return true
+	}
+
+	g.seen[fp] = g.order.PushFront(fp)
+	if g.order.Len() > g.capacity {
+		oldest := g.order.Back()
+		g.order.Remove(oldest)
+		delete(g.seen, oldest.Value.([sha256.Size]byte))
+	}
+
+	return false
+}
+
+// Len returns the number of fingerprints currently remembered
+func (g *ReplayGuard) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.order.Len()
+}
@@ -0,0 +1,50 @@
+package gaillier
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+)
+
+/*
+	Randomness reuse detection
+
+	Two ciphers sharing r under the same key are linkable : c1 / c2 = g^(m1-m2)
+	mod n^2 reveals m1 - m2. SetReuseDetection turns on a debug check remembering
+	the (key, r) pairs used by EncryptWithReader, EncryptWithRandomness and
+	ReRandomizeWithFactor and failing any encryption that repeats one.
+	It is off by default and then costs a single atomic load per encryption.
+	It is meant for tests and debugging : it keeps a fingerprint of every r,
+	and deliberate reuse (replaying test vectors) trips it as well.
+*/
+
+var reuseGuard atomic.Pointer[ReplayGuard]
+
+// SetReuseDetection remembers the last capacity (key, r) pairs and makes a repeated
+// pair fail with an error wrapping ErrInvalidRandomness, capacity <= 0 turns detection off
+func SetReuseDetection(capacity int) {
+	if capacity <= 0 {
+		reuseGuard.Store(nil)
+		return
+	}
+	reuseGuard.Store(NewReplayGuard(capacity))
+}
+
+// checkReuse records r for pubkey when detection is on and reports a reuse
+func checkReuse(pubkey *PubKey, r *big.Int) error {
+
+	g := reuseGuard.Load()
+	if g == nil {
+		return nil
+	}
+
+	n, rb := pubkey.N.Bytes(), r.Bytes()
+	id := binary.BigEndian.AppendUint64(nil, uint64(len(n)))
+	id = append(append(id, n...), rb...)
+	if g.Seen(id) {
+		return fmt.Errorf("%w: r reused under the same key", ErrInvalidRandomness)
+	}
+
+	return nil
+}
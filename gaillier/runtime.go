@@ -0,0 +1,171 @@
+package gaillier
+
+import (
+	"fmt"
+	"math/big"
+	"runtime"
+)
+
+/*
+	Runtime checks
+
+	Every cipher goes through a handful of math/big operations. Releases of Go have
+	shipped assembly carry bugs and Exp edge cases giving wrong results for some inputs,
+	silently breaking decryption. CheckRuntime recomputes each operation the package
+	relies on against an independent identity, on seeded pseudo random operands of
+	several word counts through the odd modulus (Montgomery) and even modulus paths of Exp,
+	and on the structured operands carry bugs show up with : all ones limbs (2^k - 1),
+	moduli 2^k - 1 and 2^k + 1 at word boundaries and bases m - 1.
+	Exp is checked through modExp so a custom SetModExp implementation is covered too.
+*/
+
+// runtimeCheck returns false when the operation gave a wrong result
+type runtimeCheck struct {
+	name  string
+	check func() bool
+}
+
+// runtimeInputs returns deterministic pseudo random operands of bits bits
+func runtimeInputs(label string, bits, count int) []*big.Int {
+	r := newSeedReader([]byte("gomorph runtime check " + label))
+	buf := make([]byte, (bits+7)/8)
+	xs := make([]*big.Int, count)
+	for i := range xs {
+		r.Read(buf)
+		buf[0] |= 0x80
+		xs[i] = new(big.Int).SetBytes(buf)
+	}
+	return xs
+}
+
+// edgeBits are sizes on 32 and 64 bit word boundaries
+var edgeBits = []int{64, 128, 1024, 2048}
+
+// allOnes returns 2^k - 1, every limb of it is all ones
+func allOnes(k int) *big.Int {
+	return new(big.Int).Sub(new(big.Int).Lsh(one, uint(k)), one)
+}
+
+// slowExp is square and multiply with Mul & Mod only, independent of Exp's windowing and Montgomery code
+func slowExp(x, e, m *big.Int) *big.Int {
+	res := big.NewInt(1)
+	base := new(big.Int).Mod(x, m)
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		res.Mul(res, res).Mod(res, m)
+		if e.Bit(i) == 1 {
+			res.Mul(res, base).Mod(res, m)
+		}
+	}
+	return res
+}
+
+var runtimeChecks = []runtimeCheck{
+	{"Exp odd modulus", func() bool {
+		for _, bits := range []int{64, 1024, 2048, 4096} {
+			in := runtimeInputs("exp odd", bits, 3)
+			m := in[2].SetBit(in[2], 0, 1)
+			e := new(big.Int).Rsh(in[1], uint(bits/2))
+			if modExp(in[0], e, m).Cmp(slowExp(in[0], e, m)) != 0 {
+				return false
+			}
+		}
+		return true
+	}},
+	{"Exp even modulus", func() bool {
+		in := runtimeInputs("exp even", 1024, 3)
+		m := in[2].SetBit(in[2], 0, 0)
+		e := new(big.Int).Rsh(in[1], 768)
+		return modExp(in[0], e, m).Cmp(slowExp(in[0], e, m)) == 0
+	}},
+	{"Exp edge operands", func() bool {
+		for _, k := range edgeBits {
+			for _, m := range []*big.Int{allOnes(k), new(big.Int).Add(new(big.Int).Lsh(one, uint(k)), one)} {
+				mMin := new(big.Int).Sub(m, one)
+				for _, x := range []*big.Int{mMin, allOnes(k - 1), new(big.Int).Lsh(one, uint(k-1))} {
+					for _, e := range []*big.Int{allOnes(k / 2), new(big.Int).Sub(m, big.NewInt(2))} {
+						if modExp(x, e, m).Cmp(slowExp(x, e, m)) != 0 {
+							return false
+						}
+					}
+				}
+			}
+		}
+		return true
+	}},
+	{"Exp Fermat", func() bool {
+		//2^521 - 1 is prime so a^(p-1) = 1 mod p
+		p := new(big.Int).Sub(new(big.Int).Lsh(one, 521), one)
+		a := runtimeInputs("fermat", 500, 1)[0]
+		return modExp(a, new(big.Int).Sub(p, one), p).Cmp(one) == 0
+	}},
+	{"Mul and QuoRem", func() bool {
+		for _, bits := range []int{64, 2048, 8192} {
+			in := runtimeInputs("quorem", bits, 3)
+			x := new(big.Int).Mul(in[0], in[1])
+			x.Add(x, in[2])
+			q, r := new(big.Int).QuoRem(x, in[1], new(big.Int))
+			if r.Sign() < 0 || r.Cmp(in[1]) >= 0 || q.Mul(q, in[1]).Add(q, r).Cmp(x) != 0 {
+				return false
+			}
+		}
+		return true
+	}},
+	{"Mul and QuoRem all ones", func() bool {
+		//(2^k - 1)^2 = 2^2k - 2^(k+1) + 1 carries through every limb
+		for _, k := range edgeBits {
+			x := allOnes(k)
+			sq := new(big.Int).Mul(x, x)
+			want := new(big.Int).Lsh(one, uint(2*k))
+			want.Sub(want, new(big.Int).Lsh(one, uint(k+1))).Add(want, one)
+			if sq.Cmp(want) != 0 {
+				return false
+			}
+			q, r := new(big.Int).QuoRem(sq.Add(sq, new(big.Int).Sub(x, one)), x, new(big.Int))
+			if q.Cmp(x) != 0 || r.Cmp(new(big.Int).Sub(x, one)) != 0 {
+				return false
+			}
+		}
+		return true
+	}},
+	{"ModInverse", func() bool {
+		in := runtimeInputs("inverse", 2048, 2)
+		m := in[1].SetBit(in[1], 0, 1)
+		inv := new(big.Int).ModInverse(in[0], m)
+		if inv == nil {
+			return new(big.Int).GCD(nil, nil, in[0], m).Cmp(one) != 0
+		}
+		return inv.Mul(inv, in[0]).Mod(inv, m).Cmp(one) == 0
+	}},
+	{"GCD", func() bool {
+		in := runtimeInputs("gcd", 1024, 3)
+		a, b, g := in[0], in[1], in[2]
+		d := new(big.Int).GCD(nil, nil, a, b)
+		want := new(big.Int).Mul(g, d)
+		return new(big.Int).GCD(nil, nil, new(big.Int).Mul(a, g), new(big.Int).Mul(b, g)).Cmp(want) == 0
+	}},
+	{"Sqrt", func() bool {
+		x := runtimeInputs("sqrt", 2048, 1)[0]
+		s := new(big.Int).Sqrt(x)
+		next := new(big.Int).Add(s, one)
+		return new(big.Int).Mul(s, s).Cmp(x) <= 0 && next.Mul(next, next).Cmp(x) > 0
+	}},
+	{"ProbablyPrime", func() bool {
+		p := new(big.Int).Sub(new(big.Int).Lsh(one, 521), one)
+		q := new(big.Int).Sub(new(big.Int).Lsh(one, 127), one)
+		return p.ProbablyPrime(20) && !new(big.Int).Mul(p, q).ProbablyPrime(20)
+	}},
+}
+
+// CheckRuntime recomputes the math/big operations the package depends on and returns
+// an error wrapping ErrSelfTest naming the first one giving a wrong result
+// run it once at startup, a failure means upgrading Go (or fixing a custom ModExp)
+func CheckRuntime() error {
+
+	for _, rc := range runtimeChecks {
+		if !rc.check() {
+			return fmt.Errorf("%w: math/big %s returned a wrong result on %s, upgrade Go", ErrSelfTest, rc.name, runtime.Version())
+		}
+	}
+
+	return nil
+}
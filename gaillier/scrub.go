@@ -0,0 +1,56 @@
+package gaillier
+
+import "math/big"
+
+/*
+	Scrubbed decryption
+
+	DecryptAndScrub zeroes the words of the intermediates it owns (c, c^L mod n^2,
+	L(c^L mod n^2), the product with U and m) before returning. This is best effort :
+	- big.Int.Exp, Mul & Div allocate internal temporaries that are freed without
+	  being cleared and can't be reached from here
+	- a custom ModExp (see SetModExp) may keep its own copies
+	- the garbage collector and growing slices may leave older copies behind
+	- the returned plaintext and the key itself are not touched
+	It shortens the time secret dependent values sit in reusable buffers, it is
+	not a guarantee that no copy is left in memory.
+*/
+
+// DecryptAndScrub decrypts like Decrypt and zeroes its intermediate values afterwards
+func DecryptAndScrub(privkey *PrivKey, cipher []byte) ([]byte, error) {
+
+	if err := privkey.Validate(); err != nil {
+		return nil, err
+	}
+
+	c := new(big.Int).SetBytes(cipher)
+	defer scrub(c)
+
+	if privkey.nsq().Cmp(c) < 1 {
+		return nil, ErrInvalidCiphertext
+	}
+
+	//c^l mod n^2
+	a := modExp(c, privkey.L, privkey.nsq())
+	defer scrub(a)
+
+	//L(a) = a-1 / n, computed in place
+	l := new(big.Int).Sub(a, one)
+	defer scrub(l)
+	l.Div(l, privkey.N)
+
+	prod := new(big.Int).Mul(l, privkey.U)
+	defer scrub(prod)
+
+	m := new(big.Int).Mod(prod, privkey.N)
+	defer scrub(m)
+
+	return m.Bytes(), nil
+}
+
+// scrub zeroes the whole backing array of x and resets it to 0
+func scrub(x *big.Int) {
+	w := x.Bits()
+	clear(w[:cap(w)])
+	x.SetInt64(0)
+}
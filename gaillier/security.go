@@ -0,0 +1,61 @@
+package gaillier
+
+/*
+	Security level estimates
+
+	Factoring n is the best known attack on Paillier as on RSA, so the NIST SP 800-57
+	equivalences for RSA moduli apply : 1024 bits ~ 80, 2048 ~ 112, 3072 ~ 128,
+	7680 ~ 192 and 15360 ~ 256 bits of symmetric security.
+	Sizes in between are linearly interpolated, this is a rough guide, not a proof.
+*/
+
+var securityLevels = []struct{ modulus, level int }{
+	{1024, 80},
+	{2048, 112},
+	{3072, 128},
+	{7680, 192},
+	{15360, 256},
+}
+
+// SecurityLevelBits returns the NIST equivalent symmetric security of N in bits
+// moduli below 1024 bits are scaled down from 80, above 15360 bits it stays at 256
+func (p *PubKey) SecurityLevelBits() int {
+
+	bits := p.N.BitLen()
+	first, last := securityLevels[0], securityLevels[len(securityLevels)-1]
+	switch {
+	case bits <= first.modulus:
+		return first.level * bits / first.modulus
+	case bits >= last.modulus:
+		return last.level
+	}
+
+	for i := 1; ; i++ {
+		lo, hi := securityLevels[i-1], securityLevels[i]
+		if bits <= hi.modulus {
+			return lo.level + (hi.level-lo.level)*(bits-lo.modulus)/(hi.modulus-lo.modulus)
+		}
+	}
+}
+
+// MinKeySizeForSecurity returns the smallest modulus size whose SecurityLevelBits is at least level
+// levels above 256 bits are out of the table and return 0
+func MinKeySizeForSecurity(level int) int {
+
+	first, last := securityLevels[0], securityLevels[len(securityLevels)-1]
+	switch {
+	case level > last.level:
+		return 0
+	case level <= first.level:
+		//smallest bits with 80 * bits / 1024 >= level
+		return (max(level, 0)*first.modulus + first.level - 1) / first.level
+	}
+
+	for i := 1; ; i++ {
+		lo, hi := securityLevels[i-1], securityLevels[i]
+		if level <= hi.level {
+			num, den := (level-lo.level)*(hi.modulus-lo.modulus), hi.level-lo.level
+			return lo.modulus + (num+den-1)/den
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package gaillier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// selfTestBits keeps SelfTest fast, the key is thrown away
+const selfTestBits = 256
+
+// selfTestCase computes a cipher whose plaintext is known in advance
+type selfTestCase struct {
+	name string
+	want int64
+	run  func(pub *PubKey) ([]byte, error)
+}
+
+var selfTestCases = []selfTestCase{
+	{"encrypt", 1234, func(pub *PubKey) ([]byte, error) {
+		return Encrypt(pub, big.NewInt(1234).Bytes())
+	}},
+	{"encrypt zero", 0, func(pub *PubKey) ([]byte, error) {
+		return Encrypt(pub, nil)
+	}},
+	{"add", 42, func(pub *PubKey) ([]byte, error) {
+		c, err := encryptInts(pub, 20, 22)
+		if err != nil {
+			return nil, err
+		}
+		return Add(pub, c[0], c[1]), nil
+	}},
+	{"sum", 60, func(pub *PubKey) ([]byte, error) {
+		c, err := encryptInts(pub, 10, 20, 30)
+		if err != nil {
+			return nil, err
+		}
+		return Sum(pub, c), nil
+	}},
+	{"add constant", 42, func(pub *PubKey) ([]byte, error) {
+		c, err := encryptInts(pub, 32)
+		if err != nil {
+			return nil, err
+		}
+		return AddConstant(pub, c[0], big.NewInt(10).Bytes()), nil
+	}},
+	{"mul", 320, func(pub *PubKey) ([]byte, error) {
+		c, err := encryptInts(pub, 32)
+		if err != nil {
+			return nil, err
+		}
+		return Mul(pub, c[0], big.NewInt(10).Bytes()), nil
+	}},
+	{"mul rational", 21, func(pub *PubKey) ([]byte, error) {
+		c, err := encryptInts(pub, 84)
+		if err != nil {
+			return nil, err
+		}
+		return MulRational(pub, c[0], big.NewInt(3), big.NewInt(12))
+	}},
+}
+
+// SelfTest generates a small throw-away key and checks encryption, decryption and
+// every homomorphic operation against known answers
+// services can call it at startup and refuse to serve when it returns an error
+func SelfTest() error {
+
+	pub, priv, err := GenerateKeyPair(rand.Reader, selfTestBits)
+	if err != nil {
+		return fmt.Errorf("%w: key generation: %v", ErrSelfTest, err)
+	}
+
+	for _, tc := range selfTestCases {
+		c, err := tc.run(pub)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrSelfTest, tc.name, err)
+		}
+		m, err := Decrypt(priv, c)
+		if err != nil {
+			return fmt.Errorf("%w: %s: decrypt: %v", ErrSelfTest, tc.name, err)
+		}
+		if got := new(big.Int).SetBytes(m); got.Cmp(big.NewInt(tc.want)) != 0 {
+			return fmt.Errorf("%w: %s: got %v want %d", ErrSelfTest, tc.name, got, tc.want)
+		}
+	}
+
+	return nil
+}
+
+// consistencyRounds is how many random triples ConsistencyCheck tries
+const consistencyRounds = 4
+
+// ConsistencyCheck checks the algebraic relations between the operations under
+// privkey for random a, b & k :
+// Add(Enc(a), Enc(b)) decrypts to a+b, AddConstant(Enc(a), k) to a+k
+// and Add(Enc(a), EncryptTrivial(k)) is the same cipher as AddConstant(Enc(a), k)
+// failures wrap ErrSelfTest
+func ConsistencyCheck(privkey *PrivKey) error {
+
+	if err := privkey.Validate(); err != nil {
+		return err
+	}
+	pub := &privkey.PubKey
+
+	for i := 0; i < consistencyRounds; i++ {
+		var v [3]*big.Int
+		var c [2][]byte
+		for j := range v {
+			x, err := rand.Int(rand.Reader, pub.N)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrSelfTest, err)
+			}
+			v[j] = x
+		}
+		a, b, k := v[0], v[1], v[2]
+		for j := range c {
+			x, err := Encrypt(pub, v[j].Bytes())
+			if err != nil {
+				return fmt.Errorf("%w: encrypt: %v", ErrSelfTest, err)
+			}
+			c[j] = x
+		}
+
+		if err := expectPlain(privkey, "add", Add(pub, c[0], c[1]), a, b); err != nil {
+			return err
+		}
+		withConstant := AddConstant(pub, c[0], k.Bytes())
+		if err := expectPlain(privkey, "add constant", withConstant, a, k); err != nil {
+			return err
+		}
+		trivial, err := EncryptTrivial(pub, k.Bytes())
+		if err != nil {
+			return fmt.Errorf("%w: encrypt trivial: %v", ErrSelfTest, err)
+		}
+		if !bytes.Equal(Add(pub, c[0], trivial), withConstant) {
+			return fmt.Errorf("%w: add trivial encryption differs from add constant", ErrSelfTest)
+		}
+	}
+
+	return nil
+}
+
+// expectPlain checks that cipher decrypts to x + y mod n
+func expectPlain(privkey *PrivKey, name string, cipher []byte, x, y *big.Int) error {
+
+	got, err := decrypt(privkey, cipher)
+	if err != nil {
+		return fmt.Errorf("%w: %s: decrypt: %v", ErrSelfTest, name, err)
+	}
+	want := new(big.Int).Add(x, y)
+	if got.Cmp(want.Mod(want, privkey.N)) != 0 {
+		return fmt.Errorf("%w: %s: got %v want %v", ErrSelfTest, name, got, want)
+	}
+
+	return nil
+}
+
+// encryptInts encrypts each non negative value
+func encryptInts(pub *PubKey, values ...int64) ([][]byte, error) {
+	ciphers := make([][]byte, len(values))
+	for i, v := range values {
+		c, err := Encrypt(pub, big.NewInt(v).Bytes())
+		if err != nil {
+			return nil, err
+		}
+		ciphers[i] = c
+	}
+	return ciphers, nil
+}
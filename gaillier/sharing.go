@@ -0,0 +1,91 @@
+package gaillier
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// ShareAdditive splits message into k encrypted additive shares
+// the first k-1 shares are uniformly random in Z/nZ and the last one is
+// m - (m_1 + ... + m_k-1) mod n, so Sum of the shares decrypts to message
+func ShareAdditive(pubkey *PubKey, message []byte, k int) ([][]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if k < 1 {
+		return nil, ErrInvalidShareCount
+	}
+	if !pubkey.CanEncrypt(message) {
+		return nil, ErrLongMessage
+	}
+
+	last := new(big.Int).SetBytes(message)
+	shares := make([][]byte, k)
+
+	for i := 0; i < k-1; i++ {
+		s, err := rand.Int(rand.Reader, pubkey.N)
+		if err != nil {
+			return nil, err
+		}
+		last.Sub(last, s)
+
+		if shares[i], err = Encrypt(pubkey, s.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	last.Mod(last, pubkey.N)
+	c, err := Encrypt(pubkey, last.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	shares[k-1] = c
+
+	return shares, nil
+}
+
+// GenerateZeroSumMasks returns n masks uniformly random in [0, modulus) whose sum is 0 mod modulus
+// giving one to each client of a secure aggregation (see EncryptWithMask),
+// the masks cancel in the Sum of their ciphers, modulus is the key's N
+func GenerateZeroSumMasks(n int, modulus *big.Int) ([]*big.Int, error) {
+
+	if n < 1 {
+		return nil, ErrInvalidShareCount
+	}
+	if modulus == nil || modulus.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: modulus must be positive", ErrInvalidKey)
+	}
+
+	masks := make([]*big.Int, n)
+	last := new(big.Int)
+	for i := 0; i < n-1; i++ {
+		m, err := rand.Int(rand.Reader, modulus)
+		if err != nil {
+			return nil, err
+		}
+		masks[i] = m
+		last.Sub(last, m)
+	}
+	masks[n-1] = last.Mod(last, modulus)
+
+	return masks, nil
+}
+
+// EncryptWithMask encrypts value + mask mod n, the cipher alone reveals nothing of value
+// even to the key holder, only the sum over every mask of a zero sum set does
+func EncryptWithMask(pubkey *PubKey, value, mask []byte) ([]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if !pubkey.CanEncrypt(value) {
+		return nil, ErrLongMessage
+	}
+
+	m := new(big.Int).SetBytes(value)
+	m.Add(m, new(big.Int).SetBytes(mask)).Mod(m, pubkey.N)
+
+	return Encrypt(pubkey, m.Bytes())
+}
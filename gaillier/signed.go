@@ -0,0 +1,94 @@
+package gaillier
+
+import (
+	"fmt"
+	"math/big"
+)
+
+/*
+	Signed plaintexts
+
+	Z/nZ is split in two halves : x in [0, (n-1)/2] stands for x and
+	x in [(n+1)/2, n-1] stands for x - n. n is odd so floor(n/2) = (n-1)/2 is the
+	largest positive value and -(n-1)/2 the smallest negative one.
+	Add & Mul work unchanged on signed plaintexts as long as the true result
+	stays within that range : Add(Enc(5), Enc(-3)) decrypts to 2 and
+	Add(Enc(3), Enc(-5)) to n-2, which only DecryptSigned reads back as -2.
+	Decrypt has no way to tell, always use the signed decoder on results that
+	may be negative.
+*/
+
+// EncryptSigned encrypts a possibly negative m, |m| must be at most (n-1)/2
+func EncryptSigned(pubkey *PubKey, m *big.Int) ([]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	x, err := encodeSigned(pubkey.N, m)
+	if err != nil {
+		return nil, err
+	}
+
+	return Encrypt(pubkey, x.Bytes())
+}
+
+// EncodeSigned returns the plaintext bytes of a possibly negative m for use as
+// a constant (AddConstant, Mul, MatVec ...), |m| must be at most (n-1)/2
+func EncodeSigned(pubkey *PubKey, m *big.Int) ([]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	x, err := encodeSigned(pubkey.N, m)
+	if err != nil {
+		return nil, err
+	}
+
+	return x.Bytes(), nil
+}
+
+// DecryptSigned decrypts cipher and maps plaintexts above (n-1)/2 to plaintext - n
+func DecryptSigned(privkey *PrivKey, cipher []byte) (*big.Int, error) {
+
+	m, err := decrypt(privkey, cipher)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeSigned(privkey.N, m), nil
+}
+
+// DecryptAddSigned decrypts the sum of ciphers as a signed value
+func DecryptAddSigned(privkey *PrivKey, ciphers ...[]byte) (*big.Int, error) {
+
+	if err := privkey.Validate(); err != nil {
+		return nil, err
+	}
+
+	return DecryptSigned(privkey, Sum(&privkey.PubKey, ciphers))
+}
+
+// encodeSigned maps m in [-(n-1)/2, (n-1)/2] to m mod n
+func encodeSigned(n, m *big.Int) (*big.Int, error) {
+
+	if m == nil {
+		return nil, fmt.Errorf("%w: nil value", ErrInvalidEncoding)
+	}
+	half := new(big.Int).Rsh(n, 1)
+	if new(big.Int).Abs(m).Cmp(half) > 0 {
+		return nil, ErrLongMessage
+	}
+
+	return new(big.Int).Mod(m, n), nil
+}
+
+// decodeSigned maps x in [0, n) to [-(n-1)/2, (n-1)/2]
+func decodeSigned(n, x *big.Int) *big.Int {
+
+	half := new(big.Int).Rsh(n, 1)
+	if x.Cmp(half) > 0 {
+		return x.Sub(x, n)
+	}
+
+	return x
+}
@@ -0,0 +1,80 @@
+package gaillier
+
+import (
+	"fmt"
+	"math/big"
+)
+
+/*
+	Encrypted statistics
+
+	x^2 can't be computed from Enc(x), so data owners encrypt both x and x^2.
+	The aggregator sums each column with SumPairs and the key holder gets the
+	mean and population variance from the two sums :
+	mean = sum(x) / count, variance = sum(x^2) / count - mean^2
+	Values are signed (see EncryptSigned), the sums must stay within
+	+-(n-1)/2, i.e. count * max(x^2) < n/2.
+*/
+
+// StatsEncoder encrypts data points as (x, x^2) pairs under one key
+type StatsEncoder struct {
+	pubkey *PubKey
+}
+
+// NewStatsEncoder returns a StatsEncoder encrypting under pubkey
+func NewStatsEncoder(pubkey *PubKey) *StatsEncoder {
+	return &StatsEncoder{pubkey: pubkey}
+}
+
+// EncryptPair returns encryptions of x and x^2
+func (s *StatsEncoder) EncryptPair(x int64) ([]byte, []byte, error) {
+
+	v := big.NewInt(x)
+	encX, err := EncryptSigned(s.pubkey, v)
+	if err != nil {
+		return nil, nil, err
+	}
+	encX2, err := EncryptSigned(s.pubkey, v.Mul(v, v))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return encX, encX2, nil
+}
+
+// SumPairs sums the x and x^2 columns, they must have the same length
+func SumPairs(pubkey *PubKey, xs, x2s [][]byte) ([]byte, []byte, error) {
+
+	if len(xs) != len(x2s) {
+		return nil, nil, ErrDimensionMismatch
+	}
+	if err := pubkey.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	return Sum(pubkey, xs), Sum(pubkey, x2s), nil
+}
+
+// DecryptMoments returns the mean and population variance of count points from their encrypted sums
+func DecryptMoments(privkey *PrivKey, count int, sumX, sumX2 []byte) (*big.Rat, *big.Rat, error) {
+
+	if count <= 0 {
+		return nil, nil, fmt.Errorf("%w: no data points", ErrDimensionMismatch)
+	}
+
+	sx, err := DecryptSigned(privkey, sumX)
+	if err != nil {
+		return nil, nil, err
+	}
+	sx2, err := DecryptSigned(privkey, sumX2)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := new(big.Rat).SetInt64(int64(count))
+	mean := new(big.Rat).Quo(new(big.Rat).SetInt(sx), c)
+	variance := new(big.Rat).Quo(new(big.Rat).SetInt(sx2), c)
+	variance.Sub(variance, new(big.Rat).Mul(mean, mean))
+
+	return mean, variance, nil
+}
@@ -0,0 +1,103 @@
+package gaillier
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+/*
+	Ciphertext streams
+
+	Each frame is a 4 byte big-endian length followed by that many cipher bytes.
+	A stream ends cleanly between frames, EOF inside a frame is an error.
+*/
+
+// maxFrameSize bounds a frame so a corrupted length can't trigger a huge allocation
+// 1 MiB holds the cipher of a 4 million bit key
+const maxFrameSize = 1 << 20
+
+// CiphertextWriter writes length delimited ciphers to an io.Writer
+type CiphertextWriter struct {
+	w io.Writer
+}
+
+// NewCiphertextWriter returns a CiphertextWriter writing to w
+func NewCiphertextWriter(w io.Writer) *CiphertextWriter {
+	return &CiphertextWriter{w: w}
+}
+
+// Write writes one framed cipher
+func (cw *CiphertextWriter) Write(cipher []byte) error {
+
+	if len(cipher) > maxFrameSize {
+		return fmt.Errorf("%w: cipher of %d bytes exceeds the frame limit", ErrInvalidEncoding, len(cipher))
+	}
+
+	frame := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(cipher)), uint32(len(cipher)))
+	_, err := cw.w.Write(append(frame, cipher...))
+	return err
+}
+
+// CiphertextReader reads ciphers written by a CiphertextWriter
+type CiphertextReader struct {
+	r io.Reader
+}
+
+// NewCiphertextReader returns a CiphertextReader reading from r
+func NewCiphertextReader(r io.Reader) *CiphertextReader {
+	return &CiphertextReader{r: r}
+}
+
+// Read returns the next cipher, or io.EOF once the stream ends between frames
+// a truncated frame returns an error wrapping ErrInvalidEncoding and io.ErrUnexpectedEOF
+func (cr *CiphertextReader) Read() ([]byte, error) {
+
+	var l [4]byte
+	if _, err := io.ReadFull(cr.r, l[:]); err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("%w: truncated frame length: %w", ErrInvalidEncoding, err)
+	}
+
+	size := binary.BigEndian.Uint32(l[:])
+	if size > maxFrameSize {
+		return nil, fmt.Errorf("%w: frame of %d bytes exceeds the frame limit", ErrInvalidEncoding, size)
+	}
+
+	cipher := make([]byte, size)
+	if _, err := io.ReadFull(cr.r, cipher); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("%w: truncated frame: %w", ErrInvalidEncoding, err)
+	}
+
+	return cipher, nil
+}
+
+// StreamSum adds up every cipher framed in r, holding a single cipher at a time
+// it stops at the end of the stream and returns any framing or read error
+// an empty stream sums to an encryption of zero
+func StreamSum(pubkey *PubKey, r io.Reader) ([]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+
+	cr := NewCiphertextReader(r)
+	sum := big.NewInt(1)
+	c := new(big.Int)
+	for {
+		cipher, err := cr.Read()
+		if err == io.EOF {
+			return sum.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		sum.Mod(sum.Mul(sum, c.SetBytes(cipher)), pubkey.nsq())
+	}
+}
@@ -0,0 +1,112 @@
+package gaillier
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+/*
+	Tagged ciphertexts bind a Paillier ciphertext to a context (associated data)
+	with an HMAC-SHA256 tag so it can't be replayed in another context.
+
+	Paillier is malleable by design : any homomorphic operation (Add, Mul ...)
+	produces a new ciphertext and therefore invalidates the tag.
+	Tagging is meant for storage / transport of final ciphertexts only.
+*/
+
+// TaggedCiphertext wraps a cipher and its authentication tag
+type TaggedCiphertext struct {
+	Cipher []byte
+	Tag    []byte
+}
+
+// EncryptWithAAD encrypts message and tags the result with an HMAC over
+// (cipher || aad) keyed by macKey
+func EncryptWithAAD(pubkey *PubKey, macKey, message, aad []byte) (*TaggedCiphertext, error) {
+
+	c, err := Encrypt(pubkey, message)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TaggedCiphertext{Cipher: c, Tag: computeTag(macKey, c, aad)}, nil
+}
+
+// OpenTagged verifies the tag of tc against aad then decrypts it
+func OpenTagged(privkey *PrivKey, macKey []byte, tc *TaggedCiphertext, aad []byte) ([]byte, error) {
+
+	if tc == nil {
+		return nil, fmt.Errorf("%w: nil tagged cipher", ErrInvalidEncoding)
+	}
+	if !hmac.Equal(tc.Tag, computeTag(macKey, tc.Cipher, aad)) {
+		return nil, ErrTagMismatch
+	}
+
+	return Decrypt(privkey, tc.Cipher)
+}
+
+// SealCiphertext serializes cipher with the fingerprint of its key (PubKey.Fingerprint)
+// and an HMAC over both keyed by macKey, so neither can be swapped in storage
+// layout : 4 byte big-endian cipher length, cipher, 2 byte fingerprint length, fingerprint, 32 byte tag
+// a cipher or fingerprint too long for its length field returns an error wrapping ErrInvalidEncoding
+func SealCiphertext(cipher []byte, keyFP string, macKey []byte) ([]byte, error) {
+
+	if uint64(len(cipher)) > math.MaxUint32 {
+		return nil, fmt.Errorf("%w: cipher of %d bytes is too long to seal", ErrInvalidEncoding, len(cipher))
+	}
+	if len(keyFP) > math.MaxUint16 {
+		return nil, fmt.Errorf("%w: key fingerprint of %d bytes is too long to seal", ErrInvalidEncoding, len(keyFP))
+	}
+
+	blob := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(cipher)+2+len(keyFP)+sha256.Size), uint32(len(cipher)))
+	blob = append(blob, cipher...)
+	blob = binary.BigEndian.AppendUint16(blob, uint16(len(keyFP)))
+	blob = append(blob, keyFP...)
+
+	return append(blob, computeTag(macKey, cipher, []byte(keyFP))...), nil
+}
+
+// OpenCiphertext verifies a blob produced by SealCiphertext and returns its cipher and key fingerprint
+// a malformed blob returns an error wrapping ErrInvalidEncoding, a wrong tag ErrTagMismatch
+func OpenCiphertext(blob, macKey []byte) ([]byte, string, error) {
+
+	if len(blob) < 4 {
+		return nil, "", fmt.Errorf("%w: sealed cipher too short", ErrInvalidEncoding)
+	}
+	cLen := int(binary.BigEndian.Uint32(blob))
+	rest := blob[4:]
+	if cLen < 0 || cLen > len(rest)-2 {
+		return nil, "", fmt.Errorf("%w: truncated sealed cipher", ErrInvalidEncoding)
+	}
+	cipher, rest := rest[:cLen], rest[cLen:]
+	fpLen := int(binary.BigEndian.Uint16(rest))
+	rest = rest[2:]
+	if len(rest) != fpLen+sha256.Size {
+		return nil, "", fmt.Errorf("%w: sealed cipher has %d trailing bytes, want %d", ErrInvalidEncoding, len(rest), fpLen+sha256.Size)
+	}
+	keyFP, tag := rest[:fpLen], rest[fpLen:]
+
+	if !hmac.Equal(tag, computeTag(macKey, cipher, keyFP)) {
+		return nil, "", ErrTagMismatch
+	}
+
+	return append([]byte(nil), cipher...), string(keyFP), nil
+}
+
+// computeTag returns HMAC-SHA256(key, len(cipher) || cipher || aad)
+// the length prefix keeps the boundary between cipher and aad unambiguous
+func computeTag(key, cipher, aad []byte) []byte {
+
+	var l [8]byte
+	binary.BigEndian.PutUint64(l[:], uint64(len(cipher)))
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(l[:])
+	mac.Write(cipher)
+	mac.Write(aad)
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,82 @@
+package gaillier
+
+import "fmt"
+
+/*
+	Computation transcripts
+
+	Add, Sub, Mul & AddConstant are deterministic functions of their inputs so a
+	third party holding only the public key can replay the ops a server declared
+	and compare the final cipher with the one it returned.
+	Values are numbered like registers : the inputs come first, then the result
+	of each op in order. The transcript result is the last register.
+	Re-randomized ciphers (ReRandomize, EncryptedMax, fresh encryptions) pick new
+	randomness and can't be replayed, a transcript has to end before them.
+*/
+
+// OpKind names a homomorphic op
+type OpKind int
+
+// transcript ops
+const (
+	OpAdd         OpKind = iota // A + B
+	OpSub                       // A - B
+	OpMul                       // A * Constant
+	OpAddConstant               // A + Constant
+)
+
+// Op is one transcript step, A & B index previous registers
+type Op struct {
+	Kind     OpKind
+	A, B     int
+	Constant []byte
+}
+
+// ReplayTranscript recomputes ops over inputs and returns the final cipher
+func ReplayTranscript(pubkey *PubKey, inputs [][]byte, ops []Op) ([]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%w: no inputs", ErrInvalidTranscript)
+	}
+
+	regs := append([][]byte(nil), inputs...)
+	operand := func(step, i int) ([]byte, error) {
+		if i < 0 || i >= len(regs) {
+			return nil, fmt.Errorf("%w: op %d refers to register %d, %d available", ErrInvalidTranscript, step, i, len(regs))
+		}
+		return regs[i], nil
+	}
+
+	for step, op := range ops {
+		a, err := operand(step, op.A)
+		if err != nil {
+			return nil, err
+		}
+
+		var res []byte
+		switch op.Kind {
+		case OpAdd, OpSub:
+			b, err := operand(step, op.B)
+			if err != nil {
+				return nil, err
+			}
+			if op.Kind == OpAdd {
+				res = Add(pubkey, a, b)
+			} else {
+				res = Sub(pubkey, a, b)
+			}
+		case OpMul:
+			res = Mul(pubkey, a, op.Constant)
+		case OpAddConstant:
+			res = AddConstant(pubkey, a, op.Constant)
+		default:
+			return nil, fmt.Errorf("%w: op %d has unknown kind %d", ErrInvalidTranscript, step, op.Kind)
+		}
+		regs = append(regs, res)
+	}
+
+	return regs[len(regs)-1], nil
+}
@@ -0,0 +1,136 @@
+package gaillier
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+/*
+	tss-lib key interop
+
+	Threshold signature libraries (bnb-chain/tss-lib and its forks) store their
+	Paillier keys as JSON : the embedded public key's N next to LambdaN = lcm(p-1, q-1)
+	and PhiN = (p-1)(q-1), recent versions add P & Q. They always use g = n+1.
+	Both LambdaN and PhiN are valid decryption exponents, our L is either one.
+*/
+
+// tssPrivateKey mirrors the JSON layout of tss-lib's paillier.PrivateKey
+type tssPrivateKey struct {
+	N       *big.Int
+	LambdaN *big.Int
+	PhiN    *big.Int
+	P       *big.Int `json:",omitempty"`
+	Q       *big.Int `json:",omitempty"`
+}
+
+// ImportTSSPrivateKey parses a tss-lib JSON private key
+// L is LambdaN (PhiN when LambdaN is absent), U is recomputed and the key is checked
+// to decrypt, inconsistent keys return an error wrapping ErrInvalidKey
+func ImportTSSPrivateKey(data []byte) (*PrivKey, error) {
+
+	var t tssPrivateKey
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("%w: tss key: %v", ErrInvalidEncoding, err)
+	}
+
+	pub, err := NewPubKeyFromN(t.N)
+	if err != nil {
+		return nil, err
+	}
+
+	l := t.LambdaN
+	switch {
+	case l == nil:
+		l = t.PhiN
+	case t.PhiN != nil && new(big.Int).Mod(t.PhiN, l).Sign() != 0:
+		return nil, fmt.Errorf("%w: LambdaN does not divide PhiN", ErrInvalidKey)
+	}
+	if l == nil || l.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: LambdaN or PhiN must be set", ErrInvalidKey)
+	}
+
+	k := &PrivKey{KeyLen: pub.KeyLen, PubKey: *pub, L: new(big.Int).Set(l)}
+	if t.P != nil && t.Q != nil {
+		if new(big.Int).Mul(t.P, t.Q).Cmp(t.N) != 0 {
+			return nil, fmt.Errorf("%w: P*Q is not N", ErrInvalidKey)
+		}
+		k.P, k.Q = new(big.Int).Set(t.P), new(big.Int).Set(t.Q)
+	}
+	if err := k.EnsureU(); err != nil {
+		return nil, err
+	}
+	if err := ValidatePair(k.Public(), k); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	return k, nil
+}
+
+// ExportTSSPrivateKey serializes k in tss-lib's JSON layout
+// tss-lib needs both LambdaN & PhiN, when P & Q are not set they are recovered from N
+// and L (any multiple of lambda factors n), k must use g = n+1 like tss-lib does
+func ExportTSSPrivateKey(k *PrivKey) ([]byte, error) {
+
+	if err := k.Validate(); err != nil {
+		return nil, err
+	}
+	if k.G.Cmp(new(big.Int).Add(k.N, one)) != 0 {
+		return nil, fmt.Errorf("%w: tss-lib keys use g = n+1", ErrInvalidKey)
+	}
+
+	p, q := k.P, k.Q
+	if p == nil || q == nil {
+		var err error
+		if p, q, err = factorFromExponent(k.N, k.L); err != nil {
+			return nil, err
+		}
+	}
+
+	pMin := new(big.Int).Sub(p, one)
+	qMin := new(big.Int).Sub(q, one)
+	phi := new(big.Int).Mul(pMin, qMin)
+	lambda := new(big.Int).Div(phi, new(big.Int).GCD(nil, nil, pMin, qMin))
+
+	return json.Marshal(&tssPrivateKey{
+		N:       new(big.Int).Set(k.N),
+		LambdaN: lambda,
+		PhiN:    phi,
+		P:       new(big.Int).Set(p),
+		Q:       new(big.Int).Set(q),
+	})
+}
+
+// factorFromExponent splits n = p*q given e, a multiple of lambda(n)
+// with e = 2^s*t (t odd) a random a has a^t square to a non trivial root of 1
+// for at least half the choices of a, that root minus 1 shares p or q with n
+func factorFromExponent(n, e *big.Int) (*big.Int, *big.Int, error) {
+
+	s := e.TrailingZeroBits()
+	t := new(big.Int).Rsh(e, s)
+	nMin := new(big.Int).Sub(n, one)
+
+	for attempt := 0; attempt < 64; attempt++ {
+		a, err := randomUnit(rand.Reader, n)
+		if err != nil {
+			return nil, nil, err
+		}
+		x := new(big.Int).Exp(a, t, n)
+		for i := uint(0); i < s && x.Cmp(one) != 0; i++ {
+			y := new(big.Int).Mul(x, x)
+			y.Mod(y, n)
+			if y.Cmp(one) == 0 && x.Cmp(nMin) != 0 {
+				p := new(big.Int).GCD(nil, nil, x.Sub(x, one), n)
+				q := new(big.Int).Div(n, p)
+				if p.Cmp(q) > 0 {
+					p, q = q, p
+				}
+				return p, q, nil
+			}
+			x = y
+		}
+	}
+
+	return nil, nil, fmt.Errorf("%w: L does not factor N", ErrInvalidKey)
+}
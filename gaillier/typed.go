@@ -0,0 +1,182 @@
+package gaillier
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+)
+
+/*
+	Type tagged ciphertexts
+
+	A type tagged cipher is one tag byte followed by the cipher bytes, the tag
+	names the EncodingScheme used to turn a value into a plaintext so the key
+	holder knows how to read it back. Schemes live in a package wide registry
+	keyed by tag, RegisterScheme adds or replaces one.
+	The tag is neither encrypted nor authenticated, use EncryptWithAAD for that.
+*/
+
+// EncodingScheme maps values to plaintexts of Z/nZ and back
+type EncodingScheme interface {
+	Encode(n *big.Int, v any) (*big.Int, error)
+	Decode(n *big.Int, m *big.Int) (any, error)
+}
+
+// built in type tags
+const (
+	TagRaw   byte = 0x00 // []byte read as a big endian integer
+	TagInt64 byte = 0x01 // signed int64
+	TagFixed byte = 0x02 // float64 in fixed point with 32 fractional bits
+)
+
+var (
+	schemesMu sync.RWMutex
+	schemes   = map[byte]EncodingScheme{
+		TagRaw:   rawScheme{},
+		TagInt64: int64Scheme{},
+		TagFixed: FixedPointScheme{FracBits: 32},
+	}
+)
+
+// RegisterScheme registers s under tag, replacing any previous scheme
+func RegisterScheme(tag byte, s EncodingScheme) {
+	schemesMu.Lock()
+	defer schemesMu.Unlock()
+	schemes[tag] = s
+}
+
+// lookupScheme returns the scheme registered under tag
+func lookupScheme(tag byte) (EncodingScheme, error) {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+	s, ok := schemes[tag]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown type tag %#x", ErrInvalidEncoding, tag)
+	}
+	return s, nil
+}
+
+// EncryptTagged encodes v with the scheme registered under tag and encrypts it
+func EncryptTagged(pubkey *PubKey, tag byte, v any) ([]byte, error) {
+
+	s, err := lookupScheme(tag)
+	if err != nil {
+		return nil, err
+	}
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	m, err := s.Encode(pubkey.N, v)
+	if err != nil {
+		return nil, err
+	}
+	c, err := Encrypt(pubkey, m.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{tag}, c...), nil
+}
+
+// DecodeTagged decrypts a type tagged cipher and decodes it with the scheme named by its tag
+func DecodeTagged(privkey *PrivKey, cipher []byte) (any, error) {
+
+	if len(cipher) < 2 {
+		return nil, fmt.Errorf("%w: type tagged cipher too short", ErrInvalidEncoding)
+	}
+	s, err := lookupScheme(cipher[0])
+	if err != nil {
+		return nil, err
+	}
+	m, err := Decrypt(privkey, cipher[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Decode(privkey.N, new(big.Int).SetBytes(m))
+}
+
+// AddTagged adds two type tagged ciphers, their tags must match
+func AddTagged(pubkey *PubKey, a, b []byte) ([]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if len(a) < 2 || len(b) < 2 {
+		return nil, fmt.Errorf("%w: type tagged cipher too short", ErrInvalidEncoding)
+	}
+	if a[0] != b[0] {
+		return nil, fmt.Errorf("%w: type tags %#x and %#x", ErrIncompatible, a[0], b[0])
+	}
+
+	return append([]byte{a[0]}, Add(pubkey, a[1:], b[1:])...), nil
+}
+
+// rawScheme encodes []byte as a big endian integer
+type rawScheme struct{}
+
+func (rawScheme) Encode(n *big.Int, v any) (*big.Int, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: raw scheme expects []byte, got %T", ErrInvalidEncoding, v)
+	}
+	m := new(big.Int).SetBytes(b)
+	if m.Cmp(n) >= 0 {
+		return nil, ErrLongMessage
+	}
+	return m, nil
+}
+
+func (rawScheme) Decode(n *big.Int, m *big.Int) (any, error) {
+	return m.Bytes(), nil
+}
+
+// int64Scheme encodes int64 with the signed convention of EncryptSigned
+type int64Scheme struct{}
+
+func (int64Scheme) Encode(n *big.Int, v any) (*big.Int, error) {
+	x, ok := v.(int64)
+	if !ok {
+		return nil, fmt.Errorf("%w: int64 scheme expects int64, got %T", ErrInvalidEncoding, v)
+	}
+	return encodeSigned(n, big.NewInt(x))
+}
+
+func (int64Scheme) Decode(n *big.Int, m *big.Int) (any, error) {
+	x := decodeSigned(n, m)
+	if !x.IsInt64() {
+		return nil, fmt.Errorf("%w: plaintext overflows int64", ErrInvalidEncoding)
+	}
+	return x.Int64(), nil
+}
+
+// FixedPointScheme encodes float64 as round(v * 2^FracBits) with the signed convention
+type FixedPointScheme struct {
+	FracBits uint
+}
+
+func (f FixedPointScheme) Encode(n *big.Int, v any) (*big.Int, error) {
+	x, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("%w: fixed point scheme expects float64, got %T", ErrInvalidEncoding, v)
+	}
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return nil, fmt.Errorf("%w: %v has no fixed point encoding", ErrInvalidEncoding, x)
+	}
+	scaled := new(big.Float).SetMantExp(big.NewFloat(x), int(f.FracBits))
+	// round half away from zero
+	if x < 0 {
+		scaled.Sub(scaled, big.NewFloat(0.5))
+	} else {
+		scaled.Add(scaled, big.NewFloat(0.5))
+	}
+	m, _ := scaled.Int(nil)
+	return encodeSigned(n, m)
+}
+
+func (f FixedPointScheme) Decode(n *big.Int, m *big.Int) (any, error) {
+	x := new(big.Float).SetInt(decodeSigned(n, m))
+	v, _ := x.SetMantExp(x, -int(f.FracBits)).Float64()
+	return v, nil
+}
@@ -0,0 +1,44 @@
+package gaillier
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+/*
+	Uniform ciphers
+
+	EncryptUniform always returns CiphertextSize bytes, left padded with zeros,
+	so stored records all have the same length whatever the plaintext.
+	Timing is only made more uniform, not constant :
+	- one candidate r is always n.BitLen() random bits and r^n uses the same
+	  exponent every time
+	- math/big is not constant time, g^m = 1 + m*n (or the exponentiation for a
+	  non standard g), the reductions and the rejection sampling of r still
+	  take data dependent time
+	Don't rely on it where an attacker can time many encryptions precisely.
+	Decrypt and the homomorphic ops accept the padded form unchanged.
+*/
+
+// EncryptUniform encrypts message into exactly CiphertextSize bytes
+func EncryptUniform(pubkey *PubKey, message []byte) ([]byte, error) {
+
+	if err := pubkey.Validate(); err != nil {
+		return nil, err
+	}
+	if !pubkey.CanEncrypt(message) {
+		return nil, ErrLongMessage
+	}
+
+	r, err := randomUnit(rand.Reader, pubkey.N)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReuse(pubkey, r); err != nil {
+		return nil, err
+	}
+
+	m := new(big.Int).SetBytes(message)
+
+	return encrypt(pubkey, m, r).FillBytes(make([]byte, pubkey.CiphertextSize())), nil
+}
@@ -0,0 +1,261 @@
+package gaillier
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+/*
+	Cross implementation test vectors
+
+	The JSON document holds a key, a set of (plaintext, randomness, ciphertext)
+	triples and the expected results of Add & Mul over those ciphertexts.
+	All integers are lowercase big-endian hexadecimal strings so any Paillier
+	implementation (g = n + 1) can consume them.
+
+	{
+		"bits": 512,
+		"n": "..", "g": "..", "lambda": "..", "mu": "..",
+		"encryptions": [{"m": "..", "r": "..", "c": ".."}],
+		"additions": [{"a": 0, "b": 1, "m": "..", "c": ".."}],
+		"multiplications": [{"a": 0, "k": "..", "m": "..", "c": ".."}]
+	}
+
+	additions & multiplications refer to encryptions by index
+*/
+
+// testVectorBits is the modulus size used by GenerateTestVectors
+const testVectorBits = 512
+
+type testVectors struct {
+	Bits            int                `json:"bits"`
+	N               string             `json:"n"`
+	G               string             `json:"g"`
+	Lambda          string             `json:"lambda"`
+	Mu              string             `json:"mu"`
+	Encryptions     []vectorEncryption `json:"encryptions"`
+	Additions       []vectorAddition   `json:"additions"`
+	Multiplications []vectorMul        `json:"multiplications"`
+}
+
+type vectorEncryption struct {
+	M string `json:"m"`
+	R string `json:"r"`
+	C string `json:"c"`
+}
+
+type vectorAddition struct {
+	A int    `json:"a"`
+	B int    `json:"b"`
+	M string `json:"m"`
+	C string `json:"c"`
+}
+
+type vectorMul struct {
+	A int    `json:"a"`
+	K string `json:"k"`
+	M string `json:"m"`
+	C string `json:"c"`
+}
+
+// GenerateTestVectors deterministically derives a key, encryptions and homomorphic
+// results from seed and returns them as a JSON test vector document
+// the same seed always produces the same document
+func GenerateTestVectors(seed []byte) ([]byte, error) {
+
+	random := newSeedReader(seed)
+
+	p, err := randPrime(random, testVectorBits/2)
+	if err != nil {
+		return nil, err
+	}
+	q, err := randPrime(random, testVectorBits/2)
+	if err != nil {
+		return nil, err
+	}
+	for p.Cmp(q) == 0 {
+		if q, err = randPrime(random, testVectorBits/2); err != nil {
+			return nil, err
+		}
+	}
+
+	n := new(big.Int).Mul(p, q)
+	l := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+	u, err := modInverse(l, n)
+	if err != nil {
+		return nil, err
+	}
+	priv := &PrivKey{
+		KeyLen: testVectorBits,
+		PubKey: PubKey{KeyLen: testVectorBits, N: n, G: new(big.Int).Add(n, one), Nsq: new(big.Int).Mul(n, n)},
+		L:      l,
+		U:      u,
+	}
+	pub := &priv.PubKey
+
+	tv := &testVectors{
+		Bits:   testVectorBits,
+		N:      n.Text(16),
+		G:      pub.G.Text(16),
+		Lambda: l.Text(16),
+		Mu:     priv.U.Text(16),
+	}
+
+	//edge plaintexts first then random ones
+	plaintexts := []*big.Int{big.NewInt(0), big.NewInt(1), new(big.Int).Sub(n, one)}
+	for i := 0; i < 5; i++ {
+		m, err := randomUnit(random, n)
+		if err != nil {
+			return nil, err
+		}
+		plaintexts = append(plaintexts, m)
+	}
+
+	ciphers := make([][]byte, len(plaintexts))
+	for i, m := range plaintexts {
+		r, err := randomUnit(random, n)
+		if err != nil {
+			return nil, err
+		}
+		c, err := EncryptWithRandomness(pub, m.Bytes(), r)
+		if err != nil {
+			return nil, err
+		}
+		ciphers[i] = c
+		tv.Encryptions = append(tv.Encryptions, vectorEncryption{M: m.Text(16), R: r.Text(16), C: hexInt(c)})
+	}
+
+	for i := range plaintexts {
+		j := (i + 1) % len(plaintexts)
+		m := new(big.Int).Add(plaintexts[i], plaintexts[j])
+		m.Mod(m, n)
+		tv.Additions = append(tv.Additions, vectorAddition{A: i, B: j, M: m.Text(16), C: hexInt(Add(pub, ciphers[i], ciphers[j]))})
+	}
+
+	for i := range plaintexts {
+		k, err := randomUnit(random, n)
+		if err != nil {
+			return nil, err
+		}
+		m := new(big.Int).Mul(plaintexts[i], k)
+		m.Mod(m, n)
+		tv.Multiplications = append(tv.Multiplications, vectorMul{A: i, K: k.Text(16), M: m.Text(16), C: hexInt(Mul(pub, ciphers[i], k.Bytes()))})
+	}
+
+	return json.MarshalIndent(tv, "", "\t")
+}
+
+// RunTestVectors checks a JSON test vector document against this implementation
+// it returns an error wrapping ErrTestVectorMismatch describing the first failing vector
+func RunTestVectors(data []byte) error {
+
+	tv := new(testVectors)
+	if err := json.Unmarshal(data, tv); err != nil {
+		return err
+	}
+
+	ints, err := parseHexInts(tv.N, tv.G, tv.Lambda, tv.Mu)
+	if err != nil {
+		return err
+	}
+	n, g, l, u := ints[0], ints[1], ints[2], ints[3]
+	priv := &PrivKey{
+		KeyLen: tv.Bits,
+		PubKey: PubKey{KeyLen: tv.Bits, N: n, G: g, Nsq: new(big.Int).Mul(n, n)},
+		L:      l,
+		U:      u,
+	}
+	pub := &priv.PubKey
+
+	ciphers := make([][]byte, len(tv.Encryptions))
+	for i, e := range tv.Encryptions {
+		ints, err := parseHexInts(e.M, e.R, e.C)
+		if err != nil {
+			return err
+		}
+		m, r, want := ints[0], ints[1], ints[2]
+
+		c, err := EncryptWithRandomness(pub, m.Bytes(), r)
+		if err != nil {
+			return fmt.Errorf("%w: encryption #%d: %v", ErrTestVectorMismatch, i, err)
+		}
+		if err := checkVector(priv, c, want, m); err != nil {
+			return fmt.Errorf("%w: encryption #%d: %v", ErrTestVectorMismatch, i, err)
+		}
+		ciphers[i] = c
+	}
+
+	for i, a := range tv.Additions {
+		if !inRange(len(ciphers), a.A, a.B) {
+			return fmt.Errorf("%w: addition #%d: operand out of range", ErrTestVectorMismatch, i)
+		}
+		ints, err := parseHexInts(a.M, a.C)
+		if err != nil {
+			return err
+		}
+		if err := checkVector(priv, Add(pub, ciphers[a.A], ciphers[a.B]), ints[1], ints[0]); err != nil {
+			return fmt.Errorf("%w: addition #%d: %v", ErrTestVectorMismatch, i, err)
+		}
+	}
+
+	for i, mul := range tv.Multiplications {
+		if !inRange(len(ciphers), mul.A) {
+			return fmt.Errorf("%w: multiplication #%d: operand out of range", ErrTestVectorMismatch, i)
+		}
+		ints, err := parseHexInts(mul.K, mul.M, mul.C)
+		if err != nil {
+			return err
+		}
+		if err := checkVector(priv, Mul(pub, ciphers[mul.A], ints[0].Bytes()), ints[2], ints[1]); err != nil {
+			return fmt.Errorf("%w: multiplication #%d: %v", ErrTestVectorMismatch, i, err)
+		}
+	}
+
+	return nil
+}
+
+// checkVector compares a computed cipher with the expected one and its decryption with m
+func checkVector(priv *PrivKey, c []byte, want, m *big.Int) error {
+
+	if got := new(big.Int).SetBytes(c); got.Cmp(want) != 0 {
+		return fmt.Errorf("ciphertext got %s want %s", got.Text(16), want.Text(16))
+	}
+
+	d, err := Decrypt(priv, c)
+	if err != nil {
+		return err
+	}
+	if got := new(big.Int).SetBytes(d); got.Cmp(m) != 0 {
+		return fmt.Errorf("plaintext got %s want %s", got.Text(16), m.Text(16))
+	}
+
+	return nil
+}
+
+func hexInt(b []byte) string {
+	return new(big.Int).SetBytes(b).Text(16)
+}
+
+func parseHexInts(s ...string) ([]*big.Int, error) {
+
+	ints := make([]*big.Int, len(s))
+	for i, v := range s {
+		x, ok := new(big.Int).SetString(v, 16)
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid hex integer %q", ErrTestVectorMismatch, v)
+		}
+		ints[i] = x
+	}
+
+	return ints, nil
+}
+
+func inRange(n int, idx ...int) bool {
+	for _, i := range idx {
+		if i < 0 || i >= n {
+			return false
+		}
+	}
+	return true
+}
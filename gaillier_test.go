@@ -2,11 +2,15 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/gob"
+	"errors"
 	"fmt"
 	"math/big"
 	"testing"
+	"testing/quick"
+	"time"
 
 	"github.com/duncandean/gomorph/gaillier"
 )
@@ -144,6 +148,38 @@ func TestAddConstant(t *testing.T) {
 
 }
 
+func TestAddConstantReducesModN(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	m := big.NewInt(32)
+	keys := map[string]*gaillier.PrivKey{"standard g": priv, "general g": generalGKey(t, priv)}
+
+	for name, k := range map[string]*big.Int{
+		"n":     new(big.Int).Set(pub.N),
+		"n+5":   new(big.Int).Add(pub.N, big.NewInt(5)),
+		"3n+7":  new(big.Int).Add(new(big.Int).Mul(pub.N, big.NewInt(3)), big.NewInt(7)),
+		"n^2+1": new(big.Int).Add(pub.Nsq, big.NewInt(1)),
+	} {
+		for keyName, key := range keys {
+			c := mustEncrypt(t, &key.PubKey, m.Bytes())
+			d, err := gaillier.Decrypt(key, gaillier.AddConstant(&key.PubKey, c, k.Bytes()))
+			if err != nil {
+				t.Fatalf("Error Decrypt %v", err)
+			}
+			want := new(big.Int).Add(m, k)
+			want.Mod(want, pub.N)
+			if got := new(big.Int).SetBytes(d); got.Cmp(want) != 0 {
+				t.Errorf("Error %s AddConstant k = %s got %v want %v", keyName, name, got, want)
+			}
+		}
+	}
+}
+
 func TestMul(t *testing.T) {
 
 	k := new(big.Int).SetInt64(10)
@@ -173,3 +209,654 @@ func TestMul(t *testing.T) {
 		t.Errorf("Error Mul function want %d , got %d", corr, result)
 	}
 }
+
+func TestCanEncrypt(t *testing.T) {
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	below := new(big.Int).Sub(pub.N, big.NewInt(1))
+	above := new(big.Int).Add(pub.N, big.NewInt(1))
+
+	if !pub.CanEncrypt(below.Bytes()) {
+		t.Errorf("CanEncrypt rejected n-1, want accepted")
+	}
+	if pub.CanEncrypt(pub.N.Bytes()) {
+		t.Errorf("CanEncrypt accepted n, want rejected")
+	}
+	if pub.CanEncrypt(above.Bytes()) {
+		t.Errorf("CanEncrypt accepted n+1, want rejected")
+	}
+
+	//CanEncrypt must agree with Encrypt
+	if _, err := gaillier.Encrypt(pub, below.Bytes()); err != nil {
+		t.Errorf("Encrypt failed on n-1 : %v", err)
+	}
+	if _, err := gaillier.Encrypt(pub, pub.N.Bytes()); err != gaillier.ErrLongMessage {
+		t.Errorf("Encrypt on n got %v want %v", err, gaillier.ErrLongMessage)
+	}
+}
+
+func TestKeyGenDistinctPrimes(t *testing.T) {
+
+	//with 10 bits p & q are picked among {29, 31} so they collide half of the time
+	for i := 0; i < 32; i++ {
+		pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 10)
+		if err != nil {
+			t.Fatalf("Error Generating Keypair %v", err)
+		}
+
+		root := new(big.Int).Sqrt(pub.N)
+		if new(big.Int).Mul(root, root).Cmp(pub.N) == 0 {
+			t.Fatalf("Generated modulus %v is a perfect square, p == q", pub.N)
+		}
+
+		m := new(big.Int).SetInt64(42)
+		c, err := gaillier.Encrypt(pub, m.Bytes())
+		if err != nil {
+			t.Fatalf("Failed to encrypt %v", err)
+		}
+		d, err := gaillier.Decrypt(priv, c)
+		if err != nil || new(big.Int).SetBytes(d).Cmp(m) != 0 {
+			t.Fatalf("Error decrypting with tiny key got %v want %v (%v)", new(big.Int).SetBytes(d), m, err)
+		}
+	}
+
+	//with 8 bits the only candidate prime is 13 so generation must fail
+	if _, _, err := gaillier.GenerateKeyPair(rand.Reader, 8); err != gaillier.ErrInvalidPrimes {
+		t.Errorf("Generating 8 bit keypair got %v want %v", err, gaillier.ErrInvalidPrimes)
+	}
+}
+
+func TestEncryptSafe(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	//strict mode rejects every spelling of zero
+	for _, zero := range [][]byte{nil, {}, {0}, {0, 0, 0}} {
+		if _, err := gaillier.EncryptSafe(pub, zero); err != gaillier.ErrEmptyMessage {
+			t.Errorf("EncryptSafe(%v) got %v want %v", zero, err, gaillier.ErrEmptyMessage)
+		}
+	}
+
+	//opting in encrypts zero
+	c, err := gaillier.EncryptSafe(pub, []byte{0}, gaillier.AllowZeroMessage())
+	if err != nil {
+		t.Errorf("EncryptSafe with AllowZeroMessage failed %v", err)
+	}
+	d, err := gaillier.Decrypt(priv, c)
+	if err != nil || new(big.Int).SetBytes(d).Sign() != 0 {
+		t.Errorf("Error decrypting zero got %v (%v)", d, err)
+	}
+
+	//non zero messages behave like Encrypt in both modes
+	m := big.NewInt(7)
+	for _, opts := range [][]gaillier.EncryptOption{nil, {gaillier.AllowZeroMessage()}} {
+		c, err := gaillier.EncryptSafe(pub, m.Bytes(), opts...)
+		if err != nil {
+			t.Errorf("EncryptSafe failed %v", err)
+		}
+		d, _ := gaillier.Decrypt(priv, c)
+		if new(big.Int).SetBytes(d).Cmp(m) != 0 {
+			t.Errorf("Error decrypting got %v want %v", new(big.Int).SetBytes(d), m)
+		}
+	}
+
+	//default Encrypt is unchanged
+	if _, err := gaillier.Encrypt(pub, nil); err != nil {
+		t.Errorf("Encrypt of zero should still succeed got %v", err)
+	}
+}
+
+func TestEnsureU(t *testing.T) {
+
+	m := new(big.Int).SetInt64(31337)
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	c, err := gaillier.Encrypt(pub, m.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to encrypt %v", err)
+	}
+
+	u := priv.U
+	priv.U = nil
+	if err := priv.EnsureU(); err != nil {
+		t.Fatalf("EnsureU failed %v", err)
+	}
+	if priv.U.Cmp(u) != 0 {
+		t.Errorf("EnsureU got %v want %v", priv.U, u)
+	}
+
+	d, err := gaillier.Decrypt(priv, c)
+	if err != nil || new(big.Int).SetBytes(d).Cmp(m) != 0 {
+		t.Errorf("Error decrypting after EnsureU got %v want %v (%v)", new(big.Int).SetBytes(d), m, err)
+	}
+
+	//partial import through gob
+	priv.U = nil
+	buffer := new(bytes.Buffer)
+	if err := gob.NewEncoder(buffer).Encode(priv); err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	decoded := new(gaillier.PrivKey)
+	if err := gob.NewDecoder(buffer).Decode(decoded); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	d, err = gaillier.Decrypt(decoded, c)
+	if err != nil || new(big.Int).SetBytes(d).Cmp(m) != 0 {
+		t.Errorf("Error decrypting with decoded key got %v want %v (%v)", new(big.Int).SetBytes(d), m, err)
+	}
+
+	priv.U, priv.L = nil, nil
+	if err := priv.EnsureU(); !errors.Is(err, gaillier.ErrInvalidKey) {
+		t.Errorf("EnsureU without L got %v want %v", err, gaillier.ErrInvalidKey)
+	}
+}
+
+func TestPrivKeyEncodeDecode(t *testing.T) {
+
+	m := new(big.Int).SetInt64(99)
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	buffer := new(bytes.Buffer)
+	if err := gob.NewEncoder(buffer).Encode(priv); err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	decoded := new(gaillier.PrivKey)
+	if err := gob.NewDecoder(buffer).Decode(decoded); err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+
+	if decoded.KeyLen != priv.KeyLen || decoded.N.Cmp(priv.N) != 0 || decoded.L.Cmp(priv.L) != 0 || decoded.U.Cmp(priv.U) != 0 {
+		t.Errorf("Decoded private key differs from the original")
+	}
+
+	c, _ := gaillier.Encrypt(pub, m.Bytes())
+	d, err := gaillier.Decrypt(decoded, c)
+	if err != nil || new(big.Int).SetBytes(d).Cmp(m) != 0 {
+		t.Errorf("Error decrypting with decoded key got %v want %v (%v)", new(big.Int).SetBytes(d), m, err)
+	}
+}
+
+// quickOperand maps a generated value into Z/nZ, near the top of the ring when top is set
+func quickOperand(n *big.Int, v uint64, top bool) *big.Int {
+	x := new(big.Int).SetUint64(v)
+	if top {
+		x.Sub(n, x.Add(x, big.NewInt(1)))
+	}
+	return x.Mod(x, n)
+}
+
+func TestQuickProperties(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 256)
+
+	if err != nil {
+		t.Fatalf("Error Generating Keypair")
+	}
+	n := pub.N
+	cfg := &quick.Config{MaxCount: 50}
+
+	enc := func(m *big.Int) []byte {
+		c, err := gaillier.Encrypt(pub, m.Bytes())
+		if err != nil {
+			t.Fatalf("Failed to encrypt %v : %v", m, err)
+		}
+		return c
+	}
+	dec := func(c []byte) *big.Int {
+		d, err := gaillier.Decrypt(priv, c)
+		if err != nil {
+			t.Fatalf("Failed to decrypt : %v", err)
+		}
+		return new(big.Int).SetBytes(d)
+	}
+	mod := func(x *big.Int) *big.Int {
+		return x.Mod(x, n)
+	}
+
+	roundTrip := func(v uint64, top bool) bool {
+		m := quickOperand(n, v, top)
+		return dec(enc(m)).Cmp(m) == 0
+	}
+	add := func(va, vb uint64, ta, tb bool) bool {
+		a, b := quickOperand(n, va, ta), quickOperand(n, vb, tb)
+		return dec(gaillier.Add(pub, enc(a), enc(b))).Cmp(mod(new(big.Int).Add(a, b))) == 0
+	}
+	mul := func(va, vk uint64, ta, tk bool) bool {
+		a, k := quickOperand(n, va, ta), quickOperand(n, vk, tk)
+		return dec(gaillier.Mul(pub, enc(a), k.Bytes())).Cmp(mod(new(big.Int).Mul(a, k))) == 0
+	}
+	addConstant := func(va, vk uint64, ta, tk bool) bool {
+		a, k := quickOperand(n, va, ta), quickOperand(n, vk, tk)
+		return dec(gaillier.AddConstant(pub, enc(a), k.Bytes())).Cmp(mod(new(big.Int).Add(a, k))) == 0
+	}
+
+	if err := quick.Check(roundTrip, cfg); err != nil {
+		t.Errorf("Decrypt(Encrypt(m)) != m : %v", err)
+	}
+	if err := quick.Check(add, cfg); err != nil {
+		t.Errorf("Decrypt(Add(Enc(a), Enc(b))) != a+b mod n : %v", err)
+	}
+	if err := quick.Check(mul, cfg); err != nil {
+		t.Errorf("Decrypt(Mul(Enc(a), k)) != a*k mod n : %v", err)
+	}
+	if err := quick.Check(addConstant, cfg); err != nil {
+		t.Errorf("Decrypt(AddConstant(Enc(a), k)) != a+k mod n : %v", err)
+	}
+}
+
+func TestKeyGenPrimeConfidence(t *testing.T) {
+
+	m := big.NewInt(515)
+
+	for _, rounds := range []int{0, 1, 64} {
+		pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512, gaillier.WithPrimeConfidence(rounds))
+		if err != nil {
+			t.Fatalf("Error Generating Keypair with %d rounds %v", rounds, err)
+		}
+		if pub.N.ProbablyPrime(rounds) {
+			t.Errorf("Modulus is prime with %d rounds", rounds)
+		}
+
+		c, _ := gaillier.Encrypt(pub, m.Bytes())
+		d, err := gaillier.Decrypt(priv, c)
+		if err != nil || new(big.Int).SetBytes(d).Cmp(m) != 0 {
+			t.Errorf("Error decrypting with %d rounds got %v want %v", rounds, new(big.Int).SetBytes(d), m)
+		}
+	}
+}
+
+func TestMulRational(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	//7 * 12 is divisible by 12, scaled by 3/12 gives 21
+	den := big.NewInt(12)
+	m := new(big.Int).Mul(big.NewInt(7), den)
+	c, _ := gaillier.Encrypt(pub, m.Bytes())
+
+	res, err := gaillier.MulRational(pub, c, big.NewInt(3), den)
+	if err != nil {
+		t.Fatalf("MulRational failed %v", err)
+	}
+	d, _ := gaillier.Decrypt(priv, res)
+	if got := new(big.Int).SetBytes(d); got.Cmp(big.NewInt(21)) != 0 {
+		t.Errorf("Error MulRational got %v want 21", got)
+	}
+
+	//den sharing a factor with n or zero has no inverse
+	for _, bad := range []*big.Int{big.NewInt(0), pub.N, new(big.Int).Mul(pub.N, big.NewInt(3))} {
+		if _, err := gaillier.MulRational(pub, c, big.NewInt(3), bad); err != gaillier.ErrNotInvertible {
+			t.Errorf("MulRational by 3/%v got %v want %v", bad, err, gaillier.ErrNotInvertible)
+		}
+	}
+}
+
+func TestReRandomizeWithFactor(t *testing.T) {
+
+	m := big.NewInt(4711)
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	c, _ := gaillier.Encrypt(pub, m.Bytes())
+
+	nc, r, err := gaillier.ReRandomizeWithFactor(pub, c)
+	if err != nil {
+		t.Fatalf("ReRandomizeWithFactor failed %v", err)
+	}
+	if bytes.Equal(nc, c) {
+		t.Errorf("Re-randomized cipher equals the original")
+	}
+
+	//cipher * r^n mod n^2 reproduces the new cipher
+	want := new(big.Int).Exp(r, pub.N, pub.Nsq)
+	want.Mod(want.Mul(want, new(big.Int).SetBytes(c)), pub.Nsq)
+	if !bytes.Equal(want.Bytes(), nc) {
+		t.Errorf("Recomputing cipher * r^n mod n^2 doesn't match the new cipher")
+	}
+
+	for _, cipher := range [][]byte{nc, mustReRandomize(t, pub, c)} {
+		d, err := gaillier.Decrypt(priv, cipher)
+		if err != nil || new(big.Int).SetBytes(d).Cmp(m) != 0 {
+			t.Errorf("Error decrypting re-randomized cipher got %v want %v (%v)", new(big.Int).SetBytes(d), m, err)
+		}
+	}
+}
+
+func mustReRandomize(t *testing.T, pub *gaillier.PubKey, c []byte) []byte {
+	nc, err := gaillier.ReRandomize(pub, c)
+	if err != nil {
+		t.Fatalf("ReRandomize failed %v", err)
+	}
+	return nc
+}
+
+func TestGLModNsq(t *testing.T) {
+
+	_, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	for name, k := range map[string]*gaillier.PrivKey{"standard g": priv, "general g": generalGKey(t, priv)} {
+		//L(x) = x-1 / n
+		l := k.GLModNsq()
+		l.Div(l.Sub(l, big.NewInt(1)), k.N)
+
+		prod := new(big.Int).Mul(l, k.U)
+		if prod.Mod(prod, k.N).Cmp(big.NewInt(1)) != 0 {
+			t.Errorf("%s : L(g^L mod n^2) * U mod n = %v want 1", name, prod)
+		}
+	}
+}
+
+func TestKeyGenExactBits(t *testing.T) {
+
+	for _, bits := range []int{256, 257, 383, 512} {
+		pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, bits, gaillier.WithExactBits())
+
+		if err != nil {
+			t.Fatalf("Error Generating Keypair %v", err)
+		}
+		if got := pub.N.BitLen(); got != bits {
+			t.Errorf("Error N bit length got %v want %v", got, bits)
+		}
+		if got, want := pub.CiphertextSize(), (2*bits+7)/8; got != want {
+			t.Errorf("Error CiphertextSize got %v want %v", got, want)
+		}
+
+		c, _ := gaillier.Encrypt(pub, []byte{42})
+		if d, _ := gaillier.Decrypt(priv, c); !bytes.Equal(d, []byte{42}) {
+			t.Errorf("Error Decrypt with %v bit key got %v want 42", bits, d)
+		}
+	}
+}
+
+func TestEncryptOne(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	c1, err := pub.EncryptOne()
+	if err != nil {
+		t.Fatalf("EncryptOne failed %v", err)
+	}
+	if c2, _ := pub.EncryptOne(); bytes.Equal(c1, c2) {
+		t.Errorf("Error EncryptOne is not randomized")
+	}
+
+	if d, _ := gaillier.Decrypt(priv, c1); new(big.Int).SetBytes(d).Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("Error EncryptOne got %v want 1", new(big.Int).SetBytes(d))
+	}
+
+	c, _ := gaillier.Encrypt(pub, big.NewInt(41).Bytes())
+	if d, _ := gaillier.Decrypt(priv, gaillier.Add(pub, c1, c)); new(big.Int).SetBytes(d).Int64() != 42 {
+		t.Errorf("Error Add EncryptOne got %v want 42", new(big.Int).SetBytes(d))
+	}
+}
+
+func TestKeyGenWithPrimes(t *testing.T) {
+
+	p, _ := rand.Prime(rand.Reader, 256)
+	q, _ := rand.Prime(rand.Reader, 256)
+	for p.Cmp(q) == 0 {
+		q, _ = rand.Prime(rand.Reader, 256)
+	}
+
+	combos := [][]gaillier.KeyOption{
+		{gaillier.WithPrimes(p, q)},
+		{gaillier.WithPrimes(p, q), gaillier.WithPrimeConfidence(40)},
+		{gaillier.WithExactBits(), gaillier.WithPrimes(q, p)},
+	}
+	for i, opts := range combos {
+		pub, priv, err := gaillier.GenerateKeyPair(nil, 512, opts...)
+		if err != nil {
+			t.Fatalf("Error GenerateKeyPair with options %d %v", i, err)
+		}
+		if pub.N.Cmp(new(big.Int).Mul(p, q)) != 0 {
+			t.Errorf("Error N is not p*q with options %d", i)
+		}
+		if err := priv.Validate(); err != nil {
+			t.Errorf("Error invalid key with options %d %v", i, err)
+		}
+		c, _ := gaillier.Encrypt(pub, []byte{42})
+		if d, _ := gaillier.Decrypt(priv, c); !bytes.Equal(d, []byte{42}) {
+			t.Errorf("Error Decrypt with options %d got %v want 42", i, d)
+		}
+	}
+
+	bad := []gaillier.KeyOption{
+		gaillier.WithPrimes(p, p),
+		gaillier.WithPrimes(p, new(big.Int).Add(q, big.NewInt(1))),
+	}
+	for _, opt := range bad {
+		if _, _, err := gaillier.GenerateKeyPair(nil, 512, opt); !errors.Is(err, gaillier.ErrInvalidPrimes) {
+			t.Errorf("Error GenerateKeyPair with bad primes got %v want ErrInvalidPrimes", err)
+		}
+	}
+	if _, _, err := gaillier.GenerateKeyPair(nil, 1024, gaillier.WithPrimes(p, q)); !errors.Is(err, gaillier.ErrInvalidPrimes) {
+		t.Errorf("Error GenerateKeyPair with mismatched size got %v want ErrInvalidPrimes", err)
+	}
+	for _, opt := range []gaillier.KeyOption{gaillier.WithPrimes(nil, q), gaillier.WithPrimes(p, nil), gaillier.WithPrimes(nil, nil)} {
+		if _, _, err := gaillier.GenerateKeyPair(rand.Reader, 512, opt); !errors.Is(err, gaillier.ErrInvalidKey) {
+			t.Errorf("Error GenerateKeyPair with a nil prime got %v want ErrInvalidKey", err)
+		}
+	}
+}
+
+func TestCarmichaelLambda(t *testing.T) {
+
+	p, _ := rand.Prime(rand.Reader, 256)
+	q, _ := rand.Prime(rand.Reader, 256)
+	for p.Cmp(q) == 0 {
+		q, _ = rand.Prime(rand.Reader, 256)
+	}
+	pMin := new(big.Int).Sub(p, big.NewInt(1))
+	qMin := new(big.Int).Sub(q, big.NewInt(1))
+	phi := new(big.Int).Mul(pMin, qMin)
+	lcm := new(big.Int).Div(phi, new(big.Int).GCD(nil, nil, pMin, qMin))
+
+	variants := []struct {
+		name   string
+		opts   []gaillier.KeyOption
+		lambda *big.Int
+	}{
+		{"phi", []gaillier.KeyOption{gaillier.WithPrimes(p, q)}, phi},
+		{"lcm", []gaillier.KeyOption{gaillier.WithPrimes(p, q), gaillier.WithCarmichaelLambda()}, lcm},
+	}
+
+	for _, v := range variants {
+		pub, priv, err := gaillier.GenerateKeyPair(nil, 512, v.opts...)
+		if err != nil {
+			t.Fatalf("Error Generating Keypair %s %v", v.name, err)
+		}
+		if priv.Lambda().Cmp(v.lambda) != 0 {
+			t.Errorf("Error %s Lambda got %v want %v", v.name, priv.Lambda(), v.lambda)
+		}
+
+		c1, _ := gaillier.Encrypt(pub, []byte{20})
+		c2, _ := gaillier.Encrypt(pub, []byte{22})
+		if d, _ := gaillier.Decrypt(priv, gaillier.Add(pub, c1, c2)); !bytes.Equal(d, []byte{42}) {
+			t.Errorf("Error %s Decrypt got %v want 42", v.name, d)
+		}
+	}
+
+	// a fresh key with the option decrypts too
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512, gaillier.WithCarmichaelLambda())
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	c, _ := gaillier.Encrypt(pub, []byte{7})
+	if d, _ := gaillier.Decrypt(priv, c); !bytes.Equal(d, []byte{7}) {
+		t.Errorf("Error Decrypt with lcm lambda got %v want 7", d)
+	}
+}
+
+func TestKeyGenOddBits(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 513)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	if pub.KeyLen != pub.N.BitLen() || priv.KeyLen != pub.N.BitLen() {
+		t.Errorf("Error KeyLen got %v / %v want N.BitLen() %v", pub.KeyLen, priv.KeyLen, pub.N.BitLen())
+	}
+	if pub.KeyLen != 512 {
+		t.Errorf("Error odd size KeyLen got %v want 512", pub.KeyLen)
+	}
+
+	exact, _, err := gaillier.GenerateKeyPair(rand.Reader, 513, gaillier.WithExactBits())
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	if exact.KeyLen != 513 || exact.N.BitLen() != 513 {
+		t.Errorf("Error exact odd size KeyLen got %v want 513", exact.KeyLen)
+	}
+}
+
+func TestNotInvertible(t *testing.T) {
+
+	p, _ := rand.Prime(rand.Reader, 256)
+	q, _ := rand.Prime(rand.Reader, 256)
+	for p.Cmp(q) == 0 {
+		q, _ = rand.Prime(rand.Reader, 256)
+	}
+	pub, priv, err := gaillier.GenerateKeyPair(nil, 512, gaillier.WithPrimes(p, q))
+	if err != nil {
+		t.Fatalf("Error Generating Keypair %v", err)
+	}
+
+	c, _ := gaillier.Encrypt(pub, []byte{6})
+
+	//a proper factor of n, and a negative multiple of it
+	for _, den := range []*big.Int{p, new(big.Int).Neg(q)} {
+		if _, err := gaillier.MulRational(pub, c, big.NewInt(1), den); !errors.Is(err, gaillier.ErrNotInvertible) {
+			t.Errorf("Error MulRational by 1/%v got %v want ErrNotInvertible", den, err)
+		}
+	}
+
+	//L = n makes L(g^L mod n^2) zero
+	bad := &gaillier.PrivKey{PubKey: priv.PubKey, KeyLen: priv.KeyLen, L: new(big.Int).Set(pub.N)}
+	if err := bad.EnsureU(); !errors.Is(err, gaillier.ErrInvalidKey) {
+		t.Errorf("Error EnsureU with a non invertible L got %v want ErrInvalidKey", err)
+	}
+}
+
+func TestKeyGenProgress(t *testing.T) {
+
+	var stages []string
+	_, _, err := gaillier.GenerateKeyPair(rand.Reader, 512, gaillier.WithProgress(func(stage string) {
+		stages = append(stages, stage)
+	}))
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	want := []string{"searching p", "p found", "searching q", "q found", "computing key"}
+	if fmt.Sprint(stages) != fmt.Sprint(want) {
+		t.Errorf("Error progress stages got %q want %q", stages, want)
+	}
+}
+
+// primeGap recovers |p - q| from n and L = (p-1)(q-1) : p + q = n - L + 1 and (p-q)^2 = (p+q)^2 - 4n
+func primeGap(priv *gaillier.PrivKey) *big.Int {
+	s := new(big.Int).Sub(priv.N, priv.L)
+	s.Add(s, big.NewInt(1))
+	d := new(big.Int).Mul(s, s)
+	d.Sub(d, new(big.Int).Lsh(priv.N, 2))
+	return d.Sqrt(d)
+}
+
+func TestKeyGenMinPrimeGap(t *testing.T) {
+
+	// 16 bit primes span 2^14 values, a 2^13 gap is often missed and forces redraws
+	const gapBits = 13
+	redraws := 0
+	for i := 0; i < 20; i++ {
+		searches := 0
+		_, priv, err := gaillier.GenerateKeyPair(rand.Reader, 32, gaillier.WithMinPrimeGap(gapBits), gaillier.WithProgress(func(stage string) {
+			if stage == "searching p" {
+				searches++
+			}
+		}))
+		if err != nil {
+			t.Fatalf("Error Generating Keypair %v", err)
+		}
+		if gap := primeGap(priv); gap.BitLen() <= gapBits {
+			t.Errorf("Error prime gap %v below 2^%d", gap, gapBits)
+		}
+		redraws += searches - 1
+	}
+	if redraws == 0 {
+		t.Errorf("Error no pair was redrawn for a too small gap")
+	}
+
+	p, _ := rand.Prime(rand.Reader, 256)
+	if _, _, err := gaillier.GenerateKeyPair(nil, 512, gaillier.WithPrimes(p, nextPrime(p)), gaillier.WithMinPrimeGap(156)); !errors.Is(err, gaillier.ErrInvalidPrimes) {
+		t.Errorf("Error close supplied primes got %v want ErrInvalidPrimes", err)
+	}
+}
+
+func TestKeyGenTimeout(t *testing.T) {
+
+	_, _, err := gaillier.GenerateKeyPairTimeout(rand.Reader, 4096, time.Nanosecond)
+	if !errors.Is(err, gaillier.ErrGenerationTimeout) {
+		t.Errorf("Error short timeout got %v want ErrGenerationTimeout", err)
+	}
+	if got := gaillier.CodeOf(err); got != gaillier.ErrCodeGenerationTimeout {
+		t.Errorf("Error timeout code got %v want %v", got, gaillier.ErrCodeGenerationTimeout)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := gaillier.GenerateKeyPairContext(ctx, rand.Reader, 512); !errors.Is(err, context.Canceled) || errors.Is(err, gaillier.ErrGenerationTimeout) {
+		t.Errorf("Error canceled context got %v want context.Canceled", err)
+	}
+
+	pub, priv, err := gaillier.GenerateKeyPairTimeout(rand.Reader, 512, time.Minute)
+	if err != nil {
+		t.Fatalf("Error Generating Keypair within the timeout %v", err)
+	}
+	c, _ := gaillier.Encrypt(pub, []byte{42})
+	if d, _ := gaillier.Decrypt(priv, c); !bytes.Equal(d, []byte{42}) {
+		t.Errorf("Error Decrypt with timeout generated key got %v want 42", d)
+	}
+}
+
+func nextPrime(p *big.Int) *big.Int {
+	q := new(big.Int).Add(p, big.NewInt(2))
+	for !q.ProbablyPrime(20) {
+		q.Add(q, big.NewInt(2))
+	}
+	return q
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestHistogram(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	bins, err := gaillier.NewHistogram(pub, 4)
+	if err != nil {
+		t.Fatalf("NewHistogram failed %v", err)
+	}
+
+	records := []int{0, 2, 2, 3, 2, 0}
+	for _, r := range records {
+		if err := gaillier.IncrementBin(pub, bins, r); err != nil {
+			t.Fatalf("IncrementBin(%d) failed %v", r, err)
+		}
+	}
+
+	want := []int64{2, 0, 3, 1}
+	for i, c := range bins {
+		d, err := gaillier.Decrypt(priv, c)
+		if err != nil {
+			t.Fatalf("Decrypt bin %d failed %v", i, err)
+		}
+		if got := new(big.Int).SetBytes(d).Int64(); got != want[i] {
+			t.Errorf("Error bin %d got %v want %v", i, got, want[i])
+		}
+	}
+
+	for _, index := range []int{-1, len(bins)} {
+		if err := gaillier.IncrementBin(pub, bins, index); !errors.Is(err, gaillier.ErrDimensionMismatch) {
+			t.Errorf("Error IncrementBin(%d) got %v want ErrDimensionMismatch", index, err)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestEncryptKeyed(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	secret := []byte("reconciliation master secret")
+
+	c1, err := gaillier.EncryptKeyed(pub, secret, []byte("record-1"), []byte{42})
+	if err != nil {
+		t.Fatalf("EncryptKeyed failed %v", err)
+	}
+	again, _ := gaillier.EncryptKeyed(pub, secret, []byte("record-1"), []byte{42})
+	if !bytes.Equal(c1, again) {
+		t.Errorf("Error EncryptKeyed did not reproduce the cipher")
+	}
+
+	c2, _ := gaillier.EncryptKeyed(pub, secret, []byte("record-2"), []byte{42})
+	if bytes.Equal(c1, c2) {
+		t.Errorf("Error different record IDs gave the same cipher")
+	}
+	other, _ := gaillier.EncryptKeyed(pub, []byte("another secret"), []byte("record-1"), []byte{42})
+	if bytes.Equal(c1, other) {
+		t.Errorf("Error different secrets gave the same cipher")
+	}
+
+	for _, c := range [][]byte{c1, c2, other} {
+		if d, _ := gaillier.Decrypt(priv, c); !bytes.Equal(d, []byte{42}) {
+			t.Errorf("Error Decrypt of keyed cipher got %v want 42", d)
+		}
+	}
+}
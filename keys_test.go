@@ -0,0 +1,542 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestSecretOnly(t *testing.T) {
+
+	m := big.NewInt(8080)
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 1024)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	secret, err := priv.MarshalSecretOnly()
+	if err != nil {
+		t.Fatalf("Failed to marshal secret %v", err)
+	}
+
+	full := new(bytes.Buffer)
+	if err := gob.NewEncoder(full).Encode(priv); err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	if len(secret) >= full.Len() {
+		t.Errorf("Secret only encoding is %d bytes, full key is %d", len(secret), full.Len())
+	}
+
+	rebuilt, err := gaillier.ReconstructPrivKey(pub, secret)
+	if err != nil {
+		t.Fatalf("Failed to reconstruct key %v", err)
+	}
+	if rebuilt.L.Cmp(priv.L) != 0 || rebuilt.U.Cmp(priv.U) != 0 || rebuilt.KeyLen != priv.KeyLen {
+		t.Errorf("Reconstructed key differs from the original")
+	}
+
+	c, _ := gaillier.Encrypt(pub, m.Bytes())
+	d, err := gaillier.Decrypt(rebuilt, c)
+	if err != nil || new(big.Int).SetBytes(d).Cmp(m) != 0 {
+		t.Errorf("Error decrypting with reconstructed key got %v want %v (%v)", new(big.Int).SetBytes(d), m, err)
+	}
+
+	//U is optional in the secret blob
+	priv.U = nil
+	secret, _ = priv.MarshalSecretOnly()
+	rebuilt, err = gaillier.ReconstructPrivKey(pub, secret)
+	if err != nil {
+		t.Fatalf("Failed to reconstruct key without U %v", err)
+	}
+	d, err = gaillier.Decrypt(rebuilt, c)
+	if err != nil || new(big.Int).SetBytes(d).Cmp(m) != 0 {
+		t.Errorf("Error decrypting with reconstructed key got %v want %v (%v)", new(big.Int).SetBytes(d), m, err)
+	}
+
+	//P & Q are kept so the rebuilt key still decrypts through CRT
+	if rebuilt.P == nil || rebuilt.P.Cmp(priv.P) != 0 || rebuilt.Q.Cmp(priv.Q) != 0 {
+		t.Errorf("Error reconstructed key lost P & Q")
+	}
+	d, err = gaillier.DecryptCRT(rebuilt, c)
+	if err != nil || new(big.Int).SetBytes(d).Cmp(m) != 0 {
+		t.Errorf("Error DecryptCRT with reconstructed key got %v want %v (%v)", new(big.Int).SetBytes(d), m, err)
+	}
+
+	//keys without P & Q
+	priv.P, priv.Q = nil, nil
+	secret, _ = priv.MarshalSecretOnly()
+	rebuilt, err = gaillier.ReconstructPrivKey(pub, secret)
+	if err != nil {
+		t.Fatalf("Failed to reconstruct key without P & Q %v", err)
+	}
+	if rebuilt.P != nil || rebuilt.Q != nil {
+		t.Errorf("Error reconstructed key got P & Q from nowhere")
+	}
+	d, err = gaillier.Decrypt(rebuilt, c)
+	if err != nil || new(big.Int).SetBytes(d).Cmp(m) != 0 {
+		t.Errorf("Error decrypting with reconstructed key got %v want %v (%v)", new(big.Int).SetBytes(d), m, err)
+	}
+
+	//a secret doesn't reconstruct under another public key
+	otherPub, _, err := gaillier.GenerateKeyPair(rand.Reader, 1024)
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	if _, err := gaillier.ReconstructPrivKey(otherPub, secret); !errors.Is(err, gaillier.ErrInvalidKey) {
+		t.Errorf("Error reconstruct under another key got %v want ErrInvalidKey", err)
+	}
+}
+
+func TestPublic(t *testing.T) {
+
+	m := big.NewInt(1001)
+
+	_, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	pub := priv.Public()
+	if pub.N.Cmp(priv.N) != 0 || pub.G.Cmp(priv.G) != 0 || pub.Nsq.Cmp(priv.Nsq) != 0 || pub.KeyLen != priv.PubKey.KeyLen {
+		t.Fatalf("Public key differs from the private key's public part")
+	}
+
+	c, _ := gaillier.Encrypt(pub, m.Bytes())
+	d, err := gaillier.Decrypt(priv, c)
+	if err != nil || new(big.Int).SetBytes(d).Cmp(m) != 0 {
+		t.Errorf("Error decrypting got %v want %v (%v)", new(big.Int).SetBytes(d), m, err)
+	}
+
+	//mutating the copy leaves the private key alone
+	n := new(big.Int).Set(priv.N)
+	pub.N.SetInt64(3)
+	pub.G.SetInt64(4)
+	pub.Nsq.SetInt64(9)
+	if priv.N.Cmp(n) != 0 || priv.G.Cmp(new(big.Int).Add(n, big.NewInt(1))) != 0 || priv.Nsq.Cmp(new(big.Int).Mul(n, n)) != 0 {
+		t.Errorf("Mutating the public copy changed the private key")
+	}
+
+	//and vice versa
+	pub = priv.Public()
+	priv.N.SetInt64(3)
+	if pub.N.Cmp(n) != 0 {
+		t.Errorf("Mutating the private key changed the public copy")
+	}
+}
+
+func TestNewPubKeyFromN(t *testing.T) {
+
+	p, _ := rand.Prime(rand.Reader, 256)
+	q, _ := rand.Prime(rand.Reader, 256)
+	for p.Cmp(q) == 0 {
+		q, _ = rand.Prime(rand.Reader, 256)
+	}
+
+	_, priv, err := gaillier.GenerateKeyPair(nil, 512, gaillier.WithPrimes(p, q))
+	if err != nil {
+		t.Fatalf("Error Generating Keypair %v", err)
+	}
+
+	pub, err := gaillier.NewPubKeyFromN(new(big.Int).Mul(p, q))
+	if err != nil {
+		t.Fatalf("NewPubKeyFromN failed %v", err)
+	}
+	if pub.KeyLen != 512 || pub.Fingerprint() != priv.Fingerprint() {
+		t.Errorf("Error NewPubKeyFromN doesn't match the generated public key")
+	}
+
+	c, _ := gaillier.Encrypt(pub, []byte{42})
+	if d, _ := gaillier.Decrypt(priv, c); !bytes.Equal(d, []byte{42}) {
+		t.Errorf("Error Decrypt got %v want 42", d)
+	}
+
+	for _, n := range []*big.Int{nil, big.NewInt(1), big.NewInt(-15), big.NewInt(100)} {
+		if _, err := gaillier.NewPubKeyFromN(n); !errors.Is(err, gaillier.ErrInvalidKey) {
+			t.Errorf("Error NewPubKeyFromN(%v) got %v want ErrInvalidKey", n, err)
+		}
+	}
+}
+
+func TestValidatePair(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	otherPub, otherPriv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	if err := gaillier.ValidatePair(pub, priv); err != nil {
+		t.Errorf("Error ValidatePair on a matching pair %v", err)
+	}
+	if err := gaillier.ValidatePair(otherPub, priv); !errors.Is(err, gaillier.ErrIncompatible) {
+		t.Errorf("Error ValidatePair on a mismatched pair got %v want ErrIncompatible", err)
+	}
+
+	// right modulus, wrong secret
+	mixed := &gaillier.PrivKey{PubKey: priv.PubKey, KeyLen: priv.KeyLen, L: otherPriv.L, U: otherPriv.U}
+	if err := gaillier.ValidatePair(pub, mixed); !errors.Is(err, gaillier.ErrIncompatible) {
+		t.Errorf("Error ValidatePair with a foreign secret got %v want ErrIncompatible", err)
+	}
+}
+
+func TestSerializationDeterministic(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	named := map[string][]byte{}
+	for i, c := range encryptRange(t, pub, 16) {
+		named[string(rune('a'+i))] = c
+	}
+
+	formats := map[string]func() ([]byte, error){
+		"gob public": func() ([]byte, error) {
+			w := new(bytes.Buffer)
+			err := gob.NewEncoder(w).Encode(pub)
+			return w.Bytes(), err
+		},
+		"gob private": func() ([]byte, error) {
+			w := new(bytes.Buffer)
+			err := gob.NewEncoder(w).Encode(priv)
+			return w.Bytes(), err
+		},
+		"json public": func() ([]byte, error) { return json.Marshal(pub) },
+		"secret only": priv.MarshalSecretOnly,
+		"base64 public": func() ([]byte, error) {
+			s, err := gaillier.PubKeyToBase64(pub)
+			return []byte(s), err
+		},
+		"base64 private": func() ([]byte, error) {
+			s, err := gaillier.PrivKeyToBase64(priv)
+			return []byte(s), err
+		},
+		"archive": func() ([]byte, error) {
+			w := new(bytes.Buffer)
+			err := gaillier.WriteArchive(w, priv, named)
+			return w.Bytes(), err
+		},
+		"test vectors": func() ([]byte, error) { return gaillier.GenerateTestVectors([]byte("seed")) },
+	}
+
+	first := map[string][]byte{}
+	for name, encode := range formats {
+		b, err := encode()
+		if err != nil {
+			t.Fatalf("Error encoding %s %v", name, err)
+		}
+		first[name] = b
+	}
+
+	//cached state must not leak into the encodings
+	pub.PrecomputeG()
+	for i := 0; i < 3; i++ {
+		for name, encode := range formats {
+			b, _ := encode()
+			if !bytes.Equal(b, first[name]) {
+				t.Errorf("Error %s encoding is not reproducible", name)
+			}
+		}
+	}
+
+	//a decoded key encodes to the same bytes
+	var back gaillier.PrivKey
+	if err := gob.NewDecoder(bytes.NewReader(first["gob private"])).Decode(&back); err != nil {
+		t.Fatalf("Error decoding private key %v", err)
+	}
+	w := new(bytes.Buffer)
+	gob.NewEncoder(w).Encode(&back)
+	if !bytes.Equal(w.Bytes(), first["gob private"]) {
+		t.Errorf("Error decoded private key encodes differently")
+	}
+}
+
+// encryptVotes only needs to encrypt and add, it gets the narrow public key
+func encryptVotes(t *testing.T, key gaillier.PublicKey, votes []int64) []byte {
+	total, err := gaillier.Encrypt(key, nil)
+	if err != nil {
+		t.Fatalf("Error Encrypt %v", err)
+	}
+	for _, v := range votes {
+		c, err := gaillier.Encrypt(key, big.NewInt(v).Bytes())
+		if err != nil {
+			t.Fatalf("Error Encrypt %v", err)
+		}
+		total = gaillier.Add(key, total, gaillier.Mul(key, c, []byte{2}))
+	}
+	return total
+}
+
+func TestPublicKeyInterface(t *testing.T) {
+
+	_, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	narrow := gaillier.PublicKey(priv.Public())
+	if _, ok := narrow.(*gaillier.PrivKey); ok {
+		t.Errorf("Error Public() still carries the private key")
+	}
+
+	//the private key is accepted too, but evaluates to the same group
+	for name, key := range map[string]gaillier.PublicKey{"narrow": narrow, "private": priv} {
+		m, _ := gaillier.Decrypt(priv, encryptVotes(t, key, []int64{1, 2, 3}))
+		if got := new(big.Int).SetBytes(m).Int64(); got != 12 {
+			t.Errorf("Error %s key got %v want 12", name, got)
+		}
+	}
+
+	if _, err := gaillier.Encrypt(nil, []byte{1}); !errors.Is(err, gaillier.ErrInvalidKey) {
+		t.Errorf("Error Encrypt nil key got %v want ErrInvalidKey", err)
+	}
+	var nilPriv *gaillier.PrivKey
+	if _, err := gaillier.Encrypt(nilPriv, []byte{1}); !errors.Is(err, gaillier.ErrInvalidKey) {
+		t.Errorf("Error Encrypt nil private key got %v want ErrInvalidKey", err)
+	}
+}
+
+func TestKeyWriteToReadFrom(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	buf := new(bytes.Buffer)
+	n, err := pub.WriteTo(buf)
+	if err != nil {
+		t.Fatalf("Error PubKey.WriteTo %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("Error PubKey.WriteTo reported %v bytes, wrote %v", n, buf.Len())
+	}
+	written := n
+
+	n, err = priv.WriteTo(buf)
+	if err != nil {
+		t.Fatalf("Error PrivKey.WriteTo %v", err)
+	}
+	if written+n != int64(buf.Len()) {
+		t.Errorf("Error PrivKey.WriteTo reported %v bytes, wrote %v", n, int64(buf.Len())-written)
+	}
+	buf.WriteString("trailer")
+
+	//both keys read back from the one stream, leaving what follows untouched
+	var pubBack gaillier.PubKey
+	if n, err = pubBack.ReadFrom(buf); err != nil || n != written {
+		t.Fatalf("Error PubKey.ReadFrom read %v bytes want %v : %v", n, written, err)
+	}
+	var privBack gaillier.PrivKey
+	if _, err = privBack.ReadFrom(buf); err != nil {
+		t.Fatalf("Error PrivKey.ReadFrom %v", err)
+	}
+	if buf.String() != "trailer" {
+		t.Errorf("Error ReadFrom consumed data after the key, left %q", buf.String())
+	}
+
+	if pubBack.N.Cmp(pub.N) != 0 || pubBack.G.Cmp(pub.G) != 0 || pubBack.Nsq.Cmp(pub.Nsq) != 0 || pubBack.KeyLen != pub.KeyLen {
+		t.Errorf("Error public key round trip mismatch")
+	}
+	if err := gaillier.ValidatePair(&pubBack, &privBack); err != nil {
+		t.Errorf("Error private key round trip %v", err)
+	}
+
+	//kinds aren't interchangeable and truncation is reported
+	buf.Reset()
+	pub.WriteTo(buf)
+	if _, err := privBack.ReadFrom(bytes.NewReader(buf.Bytes())); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+		t.Errorf("Error reading a public key as private got %v want ErrInvalidEncoding", err)
+	}
+	if _, err := pubBack.ReadFrom(bytes.NewReader(buf.Bytes()[:buf.Len()-3])); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+		t.Errorf("Error truncated key got %v want ErrInvalidEncoding", err)
+	}
+}
+
+func TestLazyNsq(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	lazy := &gaillier.PubKey{KeyLen: pub.KeyLen, N: pub.N, G: pub.G}
+	if err := lazy.Validate(); err != nil {
+		t.Fatalf("Error key without Nsq is invalid %v", err)
+	}
+
+	c, err := gaillier.Encrypt(lazy, []byte{20})
+	if err != nil {
+		t.Fatalf("Error Encrypt without Nsq %v", err)
+	}
+	ciphers, err := gaillier.ReRandomizeBatch(lazy, [][]byte{c, c, c, c})
+	if err != nil {
+		t.Fatalf("Error ReRandomizeBatch without Nsq %v", err)
+	}
+	sum := gaillier.Add(lazy, gaillier.Sum(lazy, ciphers), gaillier.Mul(lazy, c, []byte{2}))
+	if lazy.Nsq != nil {
+		t.Errorf("Error the lazy N^2 was stored in Nsq")
+	}
+	m, _ := gaillier.Decrypt(priv, sum)
+	if got := new(big.Int).SetBytes(m).Int64(); got != 120 {
+		t.Errorf("Error lazy key sum got %v want 120", got)
+	}
+
+	//copies of a key share its N^2 cache, a copy given another N must not use the cached N^2
+	pub2, priv2, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	assembled := gaillier.AssemblePubKey(pub.KeyLen, pub.N, pub.G, nil)
+	if _, err := gaillier.Encrypt(assembled, []byte{1}); err != nil {
+		t.Fatalf("Error Encrypt with assembled key %v", err)
+	}
+	moved := *assembled
+	moved.N, moved.G = pub2.N, pub2.G
+	c, err = gaillier.Encrypt(&moved, []byte{33})
+	if err != nil {
+		t.Fatalf("Error Encrypt with copied key %v", err)
+	}
+	if m, _ := gaillier.Decrypt(priv2, c); new(big.Int).SetBytes(m).Int64() != 33 {
+		t.Errorf("Error copied key with another N got %v want 33", new(big.Int).SetBytes(m))
+	}
+
+	//keys without Nsq round trip through gob without it
+	w := new(bytes.Buffer)
+	if err := gob.NewEncoder(w).Encode(lazy); err != nil {
+		t.Fatalf("Error encoding key without Nsq %v", err)
+	}
+	var back gaillier.PubKey
+	if err := gob.NewDecoder(w).Decode(&back); err != nil {
+		t.Fatalf("Error decoding key without Nsq %v", err)
+	}
+	if back.Nsq != nil || back.N.Cmp(pub.N) != 0 {
+		t.Errorf("Error key without Nsq round trip got Nsq %v", back.Nsq)
+	}
+}
+
+func benchmarkPubKeyMemory(b *testing.B, withNsq bool) {
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 2048)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		k := &gaillier.PubKey{KeyLen: pub.KeyLen, N: new(big.Int).Set(pub.N), G: new(big.Int).Set(pub.G)}
+		if withNsq {
+			k.Nsq = new(big.Int).Mul(k.N, k.N)
+		}
+	}
+}
+
+// the B/op of both show the memory a key holds with and without Nsq
+func BenchmarkPubKeyWithNsq(b *testing.B) { benchmarkPubKeyMemory(b, true) }
+
+func BenchmarkPubKeyLazyNsq(b *testing.B) { benchmarkPubKeyMemory(b, false) }
+
+func TestAssembleKeys(t *testing.T) {
+
+	m := big.NewInt(4242)
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	apub := gaillier.AssemblePubKey(pub.KeyLen, pub.N, pub.G, pub.Nsq)
+	apriv := gaillier.AssemblePrivKey(apub, priv.L, priv.U)
+
+	//ciphers cross between the generated and the assembled keys
+	c, _ := gaillier.Encrypt(apub, m.Bytes())
+	if d, _ := gaillier.Decrypt(priv, c); new(big.Int).SetBytes(d).Cmp(m) != 0 {
+		t.Errorf("Error Decrypt of assembled key cipher got %v want %v", new(big.Int).SetBytes(d), m)
+	}
+	c, _ = gaillier.Encrypt(pub, m.Bytes())
+	sum := gaillier.Add(apub, c, c)
+	if d, _ := gaillier.Decrypt(apriv, sum); new(big.Int).SetBytes(d).Int64() != 2*m.Int64() {
+		t.Errorf("Error Decrypt with assembled key got %v want %v", new(big.Int).SetBytes(d), 2*m.Int64())
+	}
+	if apub.Fingerprint() != pub.Fingerprint() {
+		t.Errorf("Error assembled key fingerprint differs")
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		gaillier.AssemblePrivKey(gaillier.AssemblePubKey(pub.KeyLen, pub.N, pub.G, pub.Nsq), priv.L, priv.U)
+	})
+	if allocs > 2 {
+		t.Errorf("Error assembling keys allocates %v times want at most 2", allocs)
+	}
+
+	npub, err := gaillier.NewPubKey(pub.KeyLen, pub.N, pub.G, nil)
+	if err != nil {
+		t.Fatalf("NewPubKey failed %v", err)
+	}
+	npriv, err := gaillier.NewPrivKey(npub, priv.L, priv.U)
+	if err != nil {
+		t.Fatalf("NewPrivKey failed %v", err)
+	}
+	if d, _ := gaillier.Decrypt(npriv, c); new(big.Int).SetBytes(d).Cmp(m) != 0 {
+		t.Errorf("Error Decrypt with NewPrivKey got %v want %v", new(big.Int).SetBytes(d), m)
+	}
+
+	if _, err := gaillier.NewPubKey(pub.KeyLen+1, pub.N, pub.G, nil); !errors.Is(err, gaillier.ErrInvalidKey) {
+		t.Errorf("Error NewPubKey wrong KeyLen got %v want ErrInvalidKey", err)
+	}
+	if _, err := gaillier.NewPubKey(pub.KeyLen, pub.N, pub.G, pub.N); !errors.Is(err, gaillier.ErrInvalidKey) {
+		t.Errorf("Error NewPubKey wrong Nsq got %v want ErrInvalidKey", err)
+	}
+	if _, err := gaillier.NewPrivKey(npub, priv.L, new(big.Int).Add(priv.U, big.NewInt(1))); !errors.Is(err, gaillier.ErrInvalidKey) {
+		t.Errorf("Error NewPrivKey wrong U got %v want ErrInvalidKey", err)
+	}
+}
+
+// AssemblePrivKey against the validated NewPrivKey, both with the same components
+func BenchmarkAssemblePrivKey(b *testing.B) {
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 2048)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		gaillier.AssemblePrivKey(gaillier.AssemblePubKey(pub.KeyLen, pub.N, pub.G, pub.Nsq), priv.L, priv.U)
+	}
+}
+
+func BenchmarkNewPrivKey(b *testing.B) {
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 2048)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		npub, _ := gaillier.NewPubKey(pub.KeyLen, pub.N, pub.G, pub.Nsq)
+		if _, err := gaillier.NewPrivKey(npub, priv.L, priv.U); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestEncryptBig(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	huge, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 2000))
+	exact := new(big.Int).Exp(pub.N, big.NewInt(3), nil)
+
+	for _, m := range []*big.Int{big.NewInt(0), big.NewInt(12345), huge, exact} {
+		ciphers, err := gaillier.EncryptBig(pub, m)
+		if err != nil {
+			t.Fatalf("EncryptBig failed %v", err)
+		}
+
+		got, err := gaillier.DecryptBig(priv, ciphers)
+		if err != nil {
+			t.Fatalf("DecryptBig failed %v", err)
+		}
+		if got.Cmp(m) != 0 {
+			t.Errorf("Error DecryptBig got %v want %v", got, m)
+		}
+	}
+
+	if ciphers, _ := gaillier.EncryptBig(pub, huge); len(ciphers) < 2000/512 {
+		t.Errorf("Error EncryptBig got %v limbs for a 2000 bit value", len(ciphers))
+	}
+}
+
+func TestEncryptChunks(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	//512 bit keys hold 62 bytes per chunk, cover aligned, unaligned and leading / trailing zeros
+	for _, size := range []int{0, 1, 61, 62, 63, 124, 200, 311} {
+		message := make([]byte, size)
+		rand.Read(message)
+		if size > 2 {
+			message[0], message[1], message[size-1] = 0, 0, 0
+		}
+
+		chunks, err := gaillier.EncryptChunks(pub, message)
+		if err != nil {
+			t.Fatalf("Error EncryptChunks %d bytes %v", size, err)
+		}
+		got, err := gaillier.DecryptChunks(priv, chunks)
+		if err != nil {
+			t.Fatalf("Error DecryptChunks %d bytes %v", size, err)
+		}
+		if !bytes.Equal(got, message) {
+			t.Errorf("Error chunk round trip of %d bytes got %x want %x", size, got, message)
+		}
+	}
+
+	chunks, _ := gaillier.EncryptChunks(pub, make([]byte, 200))
+	short, _ := gaillier.EncryptChunks(pub, []byte{1})
+	bad := [][]byte{short[0], chunks[0]}
+	if _, err := gaillier.DecryptChunks(priv, bad); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+		t.Errorf("Error DecryptChunks short inner chunk got %v want ErrInvalidEncoding", err)
+	}
+	raw, _ := gaillier.Encrypt(pub, []byte{7, 7})
+	if _, err := gaillier.DecryptChunks(priv, [][]byte{raw}); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+		t.Errorf("Error DecryptChunks without marker got %v want ErrInvalidEncoding", err)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestSetModExp(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	var calls atomic.Int64
+	prev := gaillier.SetModExp(func(base, exp, mod *big.Int) *big.Int {
+		calls.Add(1)
+		return new(big.Int).Exp(base, exp, mod)
+	})
+	defer gaillier.SetModExp(prev)
+
+	c, err := gaillier.Encrypt(pub, []byte{7})
+	if err != nil {
+		t.Fatalf("Encrypt failed %v", err)
+	}
+	if calls.Load() == 0 {
+		t.Errorf("Error custom ModExp not called during Encrypt")
+	}
+
+	before := calls.Load()
+	d, err := gaillier.Decrypt(priv, c)
+	if err != nil || !bytes.Equal(d, []byte{7}) {
+		t.Errorf("Error Decrypt got %v want 7 (%v)", d, err)
+	}
+	if calls.Load() == before {
+		t.Errorf("Error custom ModExp not called during Decrypt")
+	}
+
+	before = calls.Load()
+	d, _ = gaillier.Decrypt(priv, gaillier.Mul(pub, c, []byte{6}))
+	if !bytes.Equal(d, []byte{42}) {
+		t.Errorf("Error Mul got %v want 42", d)
+	}
+	if calls.Load()-before < 2 {
+		t.Errorf("Error custom ModExp not called during Mul")
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestOutsourcedDecryption(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	d, err := gaillier.NewDecryptDelegation(priv)
+	if err != nil {
+		t.Fatalf("Error NewDecryptDelegation %v", err)
+	}
+	if d.ServerExponent.Cmp(priv.L) == 0 {
+		t.Errorf("Error the server exponent is lambda")
+	}
+
+	//n+1-e1 must not approximate p+q, an error below n^(1/4) lets Coppersmith's method factor n
+	pq := new(big.Int).Add(priv.P, priv.Q)
+	approx := new(big.Int).Sub(new(big.Int).Add(pub.N, big.NewInt(1)), d.ServerExponent)
+	for name, x := range map[string]*big.Int{"n+1-e1": approx, "n+1-e1 mod n": new(big.Int).Mod(approx, pub.N)} {
+		diff := new(big.Int).Sub(x, pq)
+		if diff.Abs(diff).BitLen() <= pub.N.BitLen()/2 {
+			t.Errorf("Error %s is within 2^%d of p+q", name, diff.BitLen())
+		}
+	}
+
+	m, _ := rand.Int(rand.Reader, pub.N)
+	c := mustEncrypt(t, pub, m.Bytes())
+
+	//honest server
+	o, err := d.OutsourceDecryptSetup(c)
+	if err != nil {
+		t.Fatalf("Error OutsourceDecryptSetup %v", err)
+	}
+	results, err := gaillier.OutsourceExp(pub, d.ServerExponent, o.Bases)
+	if err != nil {
+		t.Fatalf("Error OutsourceExp %v", err)
+	}
+	got, err := d.OutsourceDecryptFinish(o, results)
+	if err != nil {
+		t.Fatalf("Error OutsourceDecryptFinish %v", err)
+	}
+	if new(big.Int).SetBytes(got).Cmp(m) != 0 {
+		t.Errorf("Error outsourced decryption got %x want %x", got, m)
+	}
+
+	//the server result alone doesn't decrypt
+	if x, _ := gaillier.Decrypt(priv, results[0]); bytes.Equal(x, m.Bytes()) {
+		t.Errorf("Error server result leaks the plaintext")
+	}
+
+	//a server shifting the plaintext, keeping results valid powers, is caught
+	shift := func(b []byte) []byte {
+		y := new(big.Int).SetBytes(b)
+		y.Mul(y, new(big.Int).Add(pub.N, big.NewInt(1)))
+		return y.Mod(y, pub.Nsq).Bytes()
+	}
+	tampered := map[string][][]byte{
+		"shifted result":  {shift(results[0]), results[1]},
+		"both shifted":    {shift(results[0]), shift(results[1])},
+		"garbage":         {[]byte{7}, results[1]},
+		"missing result":  {results[0]},
+		"swapped results": {results[1], results[0]},
+	}
+	for name, bad := range tampered {
+		if _, err := d.OutsourceDecryptFinish(o, bad); !errors.Is(err, gaillier.ErrVerification) {
+			t.Errorf("Error %s got %v want ErrVerification", name, err)
+		}
+	}
+
+	_, small, _ := gaillier.GenerateKeyPair(rand.Reader, 256)
+	if _, err := gaillier.NewDecryptDelegation(small); !errors.Is(err, gaillier.ErrInvalidKey) {
+		t.Errorf("Error delegation of a small key got %v want ErrInvalidKey", err)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestPermute(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	ciphers := encryptRange(t, pub, 5)
+	perm := []int{3, 0, 4, 1, 2}
+
+	out, err := gaillier.Permute(ciphers, perm)
+	if err != nil {
+		t.Fatalf("Error Permute %v", err)
+	}
+	for i, j := range perm {
+		if !bytes.Equal(out[i], ciphers[j]) {
+			t.Errorf("Error Permute position %d got a different cipher than input %d", i, j)
+		}
+	}
+
+	out, err = gaillier.Permute(ciphers, perm, gaillier.ReRandomizeOnMove(pub))
+	if err != nil {
+		t.Fatalf("Error Permute re-randomized %v", err)
+	}
+	for i, j := range perm {
+		if bytes.Equal(out[i], ciphers[j]) {
+			t.Errorf("Error re-randomized position %d kept the input bytes", i)
+		}
+		m, _ := gaillier.Decrypt(priv, out[i])
+		if got := new(big.Int).SetBytes(m).Int64(); got != int64(j) {
+			t.Errorf("Error re-randomized position %d got %v want %v", i, got, j)
+		}
+	}
+
+	for _, bad := range [][]int{{0, 1, 2, 3}, {0, 1, 2, 3, 3}, {0, 1, 2, 3, 5}, {-1, 0, 1, 2, 3}} {
+		if _, err := gaillier.Permute(ciphers, bad); !errors.Is(err, gaillier.ErrDimensionMismatch) {
+			t.Errorf("Error Permute %v got %v want ErrDimensionMismatch", bad, err)
+		}
+	}
+}
+
+func TestSelectIndices(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	ciphers := encryptRange(t, pub, 4)
+	idx := []int{2, 2, 0}
+
+	out, err := gaillier.SelectIndices(ciphers, idx, gaillier.ReRandomizeOnMove(pub))
+	if err != nil {
+		t.Fatalf("Error SelectIndices %v", err)
+	}
+	if len(out) != len(idx) {
+		t.Fatalf("Error SelectIndices length got %v want %v", len(out), len(idx))
+	}
+	if bytes.Equal(out[0], out[1]) {
+		t.Errorf("Error repeated index re-randomized to the same cipher")
+	}
+	for i, j := range idx {
+		m, _ := gaillier.Decrypt(priv, out[i])
+		if got := new(big.Int).SetBytes(m).Int64(); got != int64(j) {
+			t.Errorf("Error SelectIndices position %d got %v want %v", i, got, j)
+		}
+	}
+
+	if _, err := gaillier.SelectIndices(ciphers, []int{4}); !errors.Is(err, gaillier.ErrDimensionMismatch) {
+		t.Errorf("Error SelectIndices out of range got %v want ErrDimensionMismatch", err)
+	}
+}
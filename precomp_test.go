@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+// generalGKey returns a copy of priv using g = (n+1)^a * h^n mod n^2 instead of n+1
+func generalGKey(t testing.TB, priv *gaillier.PrivKey) *gaillier.PrivKey {
+
+	n, nsq := priv.N, priv.Nsq
+	a, err := rand.Int(rand.Reader, n)
+	if err != nil {
+		t.Fatalf("Failed to pick a %v", err)
+	}
+	h, err := rand.Int(rand.Reader, n)
+	if err != nil {
+		t.Fatalf("Failed to pick h %v", err)
+	}
+
+	g := new(big.Int).Exp(new(big.Int).Add(n, big.NewInt(1)), a, nsq)
+	g.Mod(g.Mul(g, new(big.Int).Exp(h, n, nsq)), nsq)
+
+	//mu = L(g^lambda mod n^2)^-1 mod n
+	x := new(big.Int).Exp(g, priv.L, nsq)
+	x.Div(x.Sub(x, big.NewInt(1)), n)
+	mu := new(big.Int).ModInverse(x, n)
+	if mu == nil {
+		t.Fatalf("Picked a non invertible g")
+	}
+
+	pub := gaillier.PubKey{KeyLen: priv.KeyLen, N: n, G: g, Nsq: nsq}
+	return &gaillier.PrivKey{KeyLen: priv.KeyLen, PubKey: pub, L: priv.L, U: mu}
+}
+
+func TestPrecomputeG(t *testing.T) {
+
+	_, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	gpriv := generalGKey(t, priv)
+	plain := gaillier.PubKey{KeyLen: gpriv.KeyLen, N: gpriv.N, G: gpriv.G, Nsq: gpriv.Nsq}
+	table := gaillier.PubKey{KeyLen: gpriv.KeyLen, N: gpriv.N, G: gpriv.G, Nsq: gpriv.Nsq}
+	table.PrecomputeG()
+
+	r := big.NewInt(65537)
+	for _, m := range []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(15), big.NewInt(16), new(big.Int).Sub(gpriv.N, big.NewInt(1))} {
+
+		c1, err1 := gaillier.EncryptWithRandomness(&plain, m.Bytes(), r)
+		c2, err2 := gaillier.EncryptWithRandomness(&table, m.Bytes(), r)
+		if err1 != nil || err2 != nil {
+			t.Fatalf("Failed to encrypt %v %v", err1, err2)
+		}
+
+		//c = g^m * r^n mod n^2
+		want := new(big.Int).Exp(gpriv.G, m, gpriv.Nsq)
+		want.Mod(want.Mul(want, new(big.Int).Exp(r, gpriv.N, gpriv.Nsq)), gpriv.Nsq)
+
+		if !bytes.Equal(c1, want.Bytes()) || !bytes.Equal(c2, want.Bytes()) {
+			t.Errorf("Precomputed encryption of %v differs from g^m * r^n", m)
+		}
+
+		d, err := gaillier.Decrypt(gpriv, c2)
+		if err != nil || new(big.Int).SetBytes(d).Cmp(m) != 0 {
+			t.Errorf("Error decrypting general g cipher got %v want %v (%v)", new(big.Int).SetBytes(d), m, err)
+		}
+	}
+
+	//AddConstant goes through the table too, including constants past n
+	c, _ := gaillier.EncryptWithRandomness(&table, big.NewInt(5).Bytes(), r)
+	for _, k := range []*big.Int{big.NewInt(37), new(big.Int).Add(gpriv.N, big.NewInt(37))} {
+		if !bytes.Equal(gaillier.AddConstant(&table, c, k.Bytes()), gaillier.AddConstant(&plain, c, k.Bytes())) {
+			t.Errorf("Precomputed AddConstant of %v differs from plain AddConstant", k)
+		}
+	}
+}
+
+func TestGTableConcurrentUse(t *testing.T) {
+
+	_, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	//without PrecomputeG every Encrypt takes the plain exponentiation, with it the
+	//goroutines share the table, through a copy of the key for half of them
+	for _, precompute := range []bool{false, true} {
+		gpriv := generalGKey(t, priv)
+		pub := &gpriv.PubKey
+		if precompute {
+			pub.PrecomputeG()
+		}
+		public := gpriv.Public()
+
+		const workers = 8
+		ciphers := make([][]byte, workers)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				k := pub
+				if w%2 == 1 {
+					k = public
+				}
+				ciphers[w], _ = gaillier.Encrypt(k, big.NewInt(int64(w)).Bytes())
+			}(w)
+		}
+		wg.Wait()
+
+		for w, c := range ciphers {
+			d, err := gaillier.Decrypt(gpriv, c)
+			if err != nil || new(big.Int).SetBytes(d).Int64() != int64(w) {
+				t.Errorf("Error decrypting concurrent cipher (precompute %v) got %v want %d (%v)", precompute, new(big.Int).SetBytes(d), w, err)
+			}
+		}
+	}
+
+	//a key whose G changed after PrecomputeG doesn't reuse the old table
+	gpriv := generalGKey(t, priv)
+	pub := &gpriv.PubKey
+	pub.PrecomputeG()
+	pub.G = new(big.Int).Add(pub.N, big.NewInt(1))
+	pub.G.Exp(pub.G, big.NewInt(3), pub.Nsq)
+	r := big.NewInt(65537)
+	c, _ := gaillier.EncryptWithRandomness(pub, big.NewInt(5).Bytes(), r)
+	want := new(big.Int).Exp(pub.G, big.NewInt(5), pub.Nsq)
+	want.Mod(want.Mul(want, new(big.Int).Exp(r, pub.N, pub.Nsq)), pub.Nsq)
+	if !bytes.Equal(c, want.Bytes()) {
+		t.Errorf("Error encryption after changing G used a stale table")
+	}
+}
+
+func benchmarkEncryptGeneralG(b *testing.B, precompute bool) {
+
+	_, priv, err := gaillier.GenerateKeyPair(rand.Reader, 1024)
+	if err != nil {
+		b.Fatalf("Error Generating Keypair")
+	}
+	pub := generalGKey(b, priv).PubKey
+	if precompute {
+		pub.PrecomputeG()
+	}
+	m, _ := rand.Int(rand.Reader, pub.N)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := gaillier.Encrypt(&pub, m.Bytes()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// without PrecomputeG every Encrypt computes g^m with a full exponentiation
+func BenchmarkEncryptGeneralG(b *testing.B) {
+	benchmarkEncryptGeneralG(b, false)
+}
+
+func BenchmarkEncryptGeneralGPrecomp(b *testing.B) {
+	benchmarkEncryptGeneralG(b, true)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+// randomR draws r with 0 < r < n, a random value below n is a unit with overwhelming probability
+func randomR(t *testing.T, pub *gaillier.PubKey) *big.Int {
+	for {
+		r, err := rand.Int(rand.Reader, pub.N)
+		if err != nil {
+			t.Fatalf("rand.Int failed %v", err)
+		}
+		if r.Sign() > 0 {
+			return r
+		}
+	}
+}
+
+func TestEqualPlaintextProof(t *testing.T) {
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	r1, r2, r3 := randomR(t, pub), randomR(t, pub), randomR(t, pub)
+	c1, _ := gaillier.EncryptWithRandomness(pub, []byte{42}, r1)
+	c2, _ := gaillier.EncryptWithRandomness(pub, []byte{42}, r2)
+	c3, _ := gaillier.EncryptWithRandomness(pub, []byte{43}, r3)
+
+	proof, err := gaillier.ProveEqualPlaintext(pub, c1, c2, r1, r2)
+	if err != nil {
+		t.Fatalf("ProveEqualPlaintext failed %v", err)
+	}
+	if !gaillier.VerifyEqualPlaintext(pub, c1, c2, proof) {
+		t.Errorf("Error valid equality proof rejected")
+	}
+
+	// the proof doesn't transfer to other ciphers
+	if gaillier.VerifyEqualPlaintext(pub, c1, c3, proof) {
+		t.Errorf("Error equality proof accepted for other ciphers")
+	}
+
+	// unequal plaintexts : the honest prover refuses, a forged proof is rejected
+	if _, err := gaillier.ProveEqualPlaintext(pub, c1, c3, r1, r3); err == nil {
+		t.Errorf("Error ProveEqualPlaintext accepted unequal plaintexts")
+	}
+	forged := &gaillier.EqualityProof{A: proof.A, Z: proof.Z}
+	if gaillier.VerifyEqualPlaintext(pub, c2, c3, forged) {
+		t.Errorf("Error forged proof accepted for unequal plaintexts")
+	}
+	tampered := &gaillier.EqualityProof{A: proof.A, Z: new(big.Int).Add(proof.Z, big.NewInt(1))}
+	if gaillier.VerifyEqualPlaintext(pub, c1, c2, tampered) {
+		t.Errorf("Error tampered proof accepted")
+	}
+}
+
+func TestVerifyOpening(t *testing.T) {
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	m := big.NewInt(1234)
+	r := randomR(t, pub)
+	c, err := gaillier.EncryptWithRandomness(pub, m.Bytes(), r)
+	if err != nil {
+		t.Fatalf("Error EncryptWithRandomness %v", err)
+	}
+
+	if !bytes.Equal(pub.RecomputeCiphertext(m, r), c) {
+		t.Errorf("Error RecomputeCiphertext differs from EncryptWithRandomness")
+	}
+	if !pub.VerifyOpening(c, m, r) {
+		t.Errorf("Error VerifyOpening rejected a correct opening")
+	}
+
+	for name, o := range map[string][2]*big.Int{
+		"wrong m":    {big.NewInt(1235), r},
+		"wrong r":    {m, new(big.Int).Add(r, big.NewInt(1))},
+		"m + n":      {new(big.Int).Add(m, pub.N), r},
+		"r = 0":      {m, big.NewInt(0)},
+		"negative m": {big.NewInt(-1), r},
+	} {
+		if pub.VerifyOpening(c, o[0], o[1]) {
+			t.Errorf("Error VerifyOpening accepted a %s opening", name)
+		}
+	}
+}
+
+func TestReRandomizeWithProof(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	c, _ := gaillier.Encrypt(pub, []byte{42})
+	newC, proof, err := gaillier.ReRandomizeWithProof(pub, c)
+	if err != nil {
+		t.Fatalf("ReRandomizeWithProof failed %v", err)
+	}
+	if bytes.Equal(c, newC) {
+		t.Errorf("Error ReRandomizeWithProof returned the same cipher")
+	}
+	if d, _ := gaillier.Decrypt(priv, newC); !bytes.Equal(d, []byte{42}) {
+		t.Errorf("Error re-randomized cipher got %v want 42", d)
+	}
+	if !gaillier.VerifyReRand(pub, c, newC, proof) {
+		t.Errorf("Error valid re-randomization proof rejected")
+	}
+
+	// re-randomizing while adding 1 to the plaintext : the proof no longer holds
+	inc, _ := gaillier.Encrypt(pub, []byte{1})
+	altered := gaillier.Add(pub, newC, inc)
+	if gaillier.VerifyReRand(pub, c, altered, proof) {
+		t.Errorf("Error proof accepted for an altered plaintext")
+	}
+	_, otherProof, _ := gaillier.ReRandomizeWithProof(pub, c)
+	if gaillier.VerifyReRand(pub, c, altered, otherProof) {
+		t.Errorf("Error other proof accepted for an altered plaintext")
+	}
+	if gaillier.VerifyReRand(pub, newC, c, proof) {
+		t.Errorf("Error proof accepted with the ciphers swapped")
+	}
+	if gaillier.VerifyReRand(pub, c, newC, nil) {
+		t.Errorf("Error nil proof accepted")
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestEncryptedPrivKey(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	data, err := gaillier.MarshalEncryptedPrivKey(priv, []byte("correct horse"))
+	if err != nil {
+		t.Fatalf("MarshalEncryptedPrivKey failed %v", err)
+	}
+
+	opened, err := gaillier.ParseEncryptedPrivKey(data, []byte("correct horse"))
+	if err != nil {
+		t.Fatalf("ParseEncryptedPrivKey failed %v", err)
+	}
+	if opened.L.Cmp(priv.L) != 0 || opened.U.Cmp(priv.U) != 0 || opened.N.Cmp(priv.N) != 0 {
+		t.Errorf("Error ParseEncryptedPrivKey returned a different key")
+	}
+
+	c, _ := gaillier.Encrypt(pub, []byte{42})
+	if d, _ := gaillier.Decrypt(opened, c); !bytes.Equal(d, []byte{42}) {
+		t.Errorf("Error Decrypt with opened key got %v want 42", d)
+	}
+
+	if _, err := gaillier.ParseEncryptedPrivKey(data, []byte("wrong horse")); !errors.Is(err, gaillier.ErrTagMismatch) {
+		t.Errorf("Error wrong passphrase got %v want ErrTagMismatch", err)
+	}
+}
+
+func TestEncryptedPrivKeyIterationCap(t *testing.T) {
+
+	_, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	data, err := gaillier.MarshalEncryptedPrivKey(priv, []byte("correct horse"))
+	if err != nil {
+		t.Fatalf("MarshalEncryptedPrivKey failed %v", err)
+	}
+
+	//a crafted container asking for 2^62 iterations must be refused, not derived
+	var container map[string]any
+	if err := json.Unmarshal(data, &container); err != nil {
+		t.Fatalf("Unmarshal container failed %v", err)
+	}
+	container["iterations"] = int64(1) << 62
+	crafted, _ := json.Marshal(container)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := gaillier.ParseEncryptedPrivKey(crafted, []byte("correct horse"))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, gaillier.ErrInvalidEncoding) {
+			t.Errorf("Error huge iteration count got %v want ErrInvalidEncoding", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Error ParseEncryptedPrivKey ran the KDF for a huge iteration count")
+	}
+}
+
+func TestEncryptedPrivKeyKDFVector(t *testing.T) {
+
+	_, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	//PBKDF2-HMAC-SHA256("passwd", "salt", 1) from RFC 7914 section 11, first 32 bytes
+	key, _ := hex.DecodeString("55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc")
+	block, _ := aes.NewCipher(key)
+	aead, _ := cipher.NewGCM(block)
+
+	w := new(bytes.Buffer)
+	if err := gob.NewEncoder(w).Encode(priv); err != nil {
+		t.Fatalf("Error encoding key %v", err)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+	container, _ := json.Marshal(map[string]any{
+		"version":    1,
+		"kdf":        "pbkdf2-sha256",
+		"iterations": 1,
+		"salt":       []byte("salt"),
+		"nonce":      nonce,
+		"sealed":     aead.Seal(nil, nonce, w.Bytes(), []byte("1:pbkdf2-sha256:1")),
+	})
+
+	opened, err := gaillier.ParseEncryptedPrivKey(container, []byte("passwd"))
+	if err != nil {
+		t.Fatalf("Error container sealed under the RFC PBKDF2 key didn't open %v", err)
+	}
+	if opened.L.Cmp(priv.L) != 0 {
+		t.Errorf("Error opened key differs from the sealed one")
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestProvenance(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	a := mustEncrypt(t, pub, []byte{20})
+	b := mustEncrypt(t, pub, []byte{22})
+
+	p := gaillier.AddP(pub, a, b)
+	if p.Op != "add" {
+		t.Errorf("Error AddP op got %q want add", p.Op)
+	}
+	want := []string{gaillier.CiphertextFingerprint(a), gaillier.CiphertextFingerprint(b)}
+	if !reflect.DeepEqual(p.Inputs, want) {
+		t.Errorf("Error AddP inputs got %v want %v", p.Inputs, want)
+	}
+	if p.KeyFingerprint != pub.Fingerprint() {
+		t.Errorf("Error AddP key fingerprint got %v want %v", p.KeyFingerprint, pub.Fingerprint())
+	}
+	if d, _ := gaillier.Decrypt(priv, p.Cipher); new(big.Int).SetBytes(d).Int64() != 42 {
+		t.Errorf("Error AddP cipher got %v want 42", d)
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("Marshal failed %v", err)
+	}
+	back := new(gaillier.ProvenanceCiphertext)
+	if err := json.Unmarshal(data, back); err != nil {
+		t.Fatalf("Unmarshal failed %v", err)
+	}
+	if !reflect.DeepEqual(back, p) {
+		t.Errorf("Error provenance changed through JSON got %+v want %+v", back, p)
+	}
+
+	m := gaillier.MulP(pub, p.Cipher, []byte{2})
+	if m.Op != "mul" || !bytes.Equal(m.Constant, []byte{2}) || m.Inputs[0] != gaillier.CiphertextFingerprint(p.Cipher) {
+		t.Errorf("Error MulP provenance got %+v", m)
+	}
+}
+
+func TestProvenanceReplay(t *testing.T) {
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	a := mustEncrypt(t, pub, []byte{20})
+	b := mustEncrypt(t, pub, []byte{22})
+
+	records := map[string]struct {
+		p      *gaillier.ProvenanceCiphertext
+		inputs [][]byte
+	}{
+		"add":          {gaillier.AddP(pub, a, b), [][]byte{a, b}},
+		"sub":          {gaillier.SubP(pub, b, a), [][]byte{b, a}},
+		"mul":          {gaillier.MulP(pub, a, []byte{3}), [][]byte{a}},
+		"add constant": {gaillier.AddConstantP(pub, a, []byte{5}), [][]byte{a}},
+	}
+	for name, r := range records {
+
+		//the record is checked after a trip through JSON, as stored
+		data, err := json.Marshal(r.p)
+		if err != nil {
+			t.Fatalf("Marshal failed %v", err)
+		}
+		back := new(gaillier.ProvenanceCiphertext)
+		if err := json.Unmarshal(data, back); err != nil {
+			t.Fatalf("Unmarshal failed %v", err)
+		}
+
+		op, err := back.TranscriptOp()
+		if err != nil {
+			t.Fatalf("Error %s TranscriptOp failed %v", name, err)
+		}
+		c, err := gaillier.ReplayTranscript(pub, r.inputs, []gaillier.Op{op})
+		if err != nil || !bytes.Equal(c, back.Cipher) {
+			t.Errorf("Error replaying %s record got %v want the recorded cipher", name, err)
+		}
+		if err := gaillier.VerifyProvenance(pub, back, r.inputs...); err != nil {
+			t.Errorf("Error VerifyProvenance of %s got %v want nil", name, err)
+		}
+	}
+
+	p := gaillier.AddP(pub, a, b)
+	if err := gaillier.VerifyProvenance(pub, p, b, a); !errors.Is(err, gaillier.ErrVerification) {
+		t.Errorf("Error swapped inputs got %v want ErrVerification", err)
+	}
+	forged := *p
+	forged.Cipher = gaillier.Add(pub, a, a)
+	if err := gaillier.VerifyProvenance(pub, &forged, a, b); !errors.Is(err, gaillier.ErrVerification) {
+		t.Errorf("Error forged cipher got %v want ErrVerification", err)
+	}
+	forged = *p
+	forged.Op = "div"
+	if _, err := forged.TranscriptOp(); !errors.Is(err, gaillier.ErrInvalidTranscript) {
+		t.Errorf("Error unknown op got %v want ErrInvalidTranscript", err)
+	}
+}
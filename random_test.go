@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+// flakyReader fails its first failures reads then reads from crypto/rand
+type flakyReader struct {
+	failures int
+	reads    int
+}
+
+func (f *flakyReader) Read(p []byte) (int, error) {
+	f.reads++
+	if f.failures > 0 {
+		f.failures--
+		return 0, errors.New("transient failure")
+	}
+	return rand.Read(p)
+}
+
+// zeroReader always returns zero bytes
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+func TestRandomSourceRetries(t *testing.T) {
+
+	//key generation completes once the source recovers
+	flaky := &flakyReader{failures: 3}
+	pub, priv, err := gaillier.GenerateKeyPair(gaillier.NewRandomSource(flaky, 3), 512)
+	if err != nil {
+		t.Fatalf("Key generation with a recovering source failed %v", err)
+	}
+	if flaky.reads <= 3 {
+		t.Errorf("Key generation did not read from the source")
+	}
+
+	//so does encryption
+	flaky = &flakyReader{failures: 2}
+	m := big.NewInt(66)
+	c, err := gaillier.EncryptWithReader(gaillier.NewRandomSource(flaky, 2), pub, m.Bytes())
+	if err != nil {
+		t.Fatalf("Encryption with a recovering source failed %v", err)
+	}
+	d, _ := gaillier.Decrypt(priv, c)
+	if new(big.Int).SetBytes(d).Cmp(m) != 0 {
+		t.Errorf("Error decrypting got %v want %v", new(big.Int).SetBytes(d), m)
+	}
+
+	//too many failures
+	if _, _, err := gaillier.GenerateKeyPair(gaillier.NewRandomSource(&flakyReader{failures: 4}, 3), 512); !errors.Is(err, gaillier.ErrRandomSource) {
+		t.Errorf("Key generation with a failing source got %v want %v", err, gaillier.ErrRandomSource)
+	}
+	if _, err := gaillier.EncryptWithReader(gaillier.NewRandomSource(&flakyReader{failures: 1}, 0), pub, m.Bytes()); !errors.Is(err, gaillier.ErrRandomSource) {
+		t.Errorf("Encryption with a failing source got %v want %v", err, gaillier.ErrRandomSource)
+	}
+
+	//unwrapped failing readers still surface their error
+	if _, err := gaillier.EncryptWithReader(&flakyReader{failures: 1}, pub, m.Bytes()); err == nil {
+		t.Errorf("Encryption with a failing reader succeeded")
+	}
+}
+
+func TestRandomSourceHealth(t *testing.T) {
+
+	if err := gaillier.NewRandomSource(rand.Reader, 0).Health(); err != nil {
+		t.Errorf("crypto/rand reported unhealthy %v", err)
+	}
+	if err := gaillier.NewRandomSource(&flakyReader{failures: 1}, 1).Health(); err != nil {
+		t.Errorf("Recovering source reported unhealthy %v", err)
+	}
+	if err := gaillier.NewRandomSource(&flakyReader{failures: 2}, 1).Health(); !errors.Is(err, gaillier.ErrRandomSource) {
+		t.Errorf("Failing source health got %v want %v", err, gaillier.ErrRandomSource)
+	}
+	if err := gaillier.NewRandomSource(zeroReader{}, 0).Health(); !errors.Is(err, gaillier.ErrRandomSource) {
+		t.Errorf("Stuck source health got %v want %v", err, gaillier.ErrRandomSource)
+	}
+	if err := gaillier.NewRandomSource(eofReader{}, 2).Health(); !errors.Is(err, gaillier.ErrRandomSource) {
+		t.Errorf("Exhausted source health got %v want %v", err, gaillier.ErrRandomSource)
+	}
+}
+
+type eofReader struct{}
+
+func (eofReader) Read(p []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func TestRandomnessBitsPerEncrypt(t *testing.T) {
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	perDraw := pub.RandomnessBitsPerEncrypt()
+	if perDraw != 512 {
+		t.Errorf("Error RandomnessBitsPerEncrypt got %v want 512", perDraw)
+	}
+
+	const samples = 50
+	var total int64
+	for i := 0; i < samples; i++ {
+		read, err := pub.MeasureEncryptRandomness(rand.Reader)
+		if err != nil {
+			t.Fatalf("MeasureEncryptRandomness failed %v", err)
+		}
+		// every rejected candidate costs one more full draw
+		if read == 0 || read%int64(perDraw/8) != 0 {
+			t.Errorf("Error read %v bytes, not a whole number of %v bit draws", read, perDraw)
+		}
+		total += read
+	}
+	// at most half the candidates are rejected, allow for sampling noise
+	if avg := float64(total) / samples; avg > 3*float64(perDraw/8) {
+		t.Errorf("Error average read %v bytes, want at most about %v", avg, 2*perDraw/8)
+	}
+}
+
+func TestMillerRabinFixedWitnesses(t *testing.T) {
+
+	bases := []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41}
+
+	for _, tc := range []struct {
+		n         int64
+		witnesses []uint64
+		want      bool
+	}{
+		{2, bases, true},
+		{97, bases, true},
+		{1_000_000_007, bases, true},
+		{2047, []uint64{2}, true}, //strong pseudoprime to base 2
+		{2047, []uint64{2, 3}, false},
+		{3_215_031_751, []uint64{2, 3, 5, 7}, true}, //strong pseudoprime to bases 2 .. 7
+		{3_215_031_751, bases, false},
+		{561, bases, false}, //Carmichael number
+		{1, bases, false},
+		{100, bases, false},
+	} {
+		if got := gaillier.MillerRabin(big.NewInt(tc.n), tc.witnesses); got != tc.want {
+			t.Errorf("Error MillerRabin(%d, %v) got %v want %v", tc.n, tc.witnesses, got, tc.want)
+		}
+	}
+
+	_, priv, err := gaillier.GenerateKeyPair(rand.Reader, 256, gaillier.WithFixedWitnesses(bases))
+	if err != nil {
+		t.Fatalf("Error Generating Keypair with fixed witnesses %v", err)
+	}
+	if !gaillier.MillerRabin(priv.P, bases) || !gaillier.MillerRabin(priv.Q, bases) {
+		t.Errorf("Error generated primes fail the fixed witnesses")
+	}
+}
+
+// slowReader serves chunk bytes of crypto/rand per delay
+type slowReader struct {
+	chunk int
+	delay time.Duration
+}
+
+func (s *slowReader) Read(p []byte) (int, error) {
+	time.Sleep(s.delay)
+	return rand.Read(p[:min(len(p), s.chunk)])
+}
+
+func TestProbeEntropy(t *testing.T) {
+
+	//100 bytes per 5ms is at most 20000 bytes/s
+	slow := &slowReader{chunk: 100, delay: 5 * time.Millisecond}
+	rate, err := gaillier.ProbeEntropy(slow, 2000)
+	if err != nil {
+		t.Fatalf("Error ProbeEntropy %v", err)
+	}
+	if rate > 20000 || rate < 2000 {
+		t.Errorf("Error ProbeEntropy got %.0f bytes/s want about 20000", rate)
+	}
+
+	fast, err := gaillier.ProbeEntropy(rand.Reader, 2000)
+	if err != nil || fast <= rate {
+		t.Errorf("Error ProbeEntropy crypto/rand got %.0f bytes/s (%v) want more than %.0f", fast, err, rate)
+	}
+
+	if _, err := gaillier.ProbeEntropy(eofReader{}, 16); !errors.Is(err, gaillier.ErrRandomSource) {
+		t.Errorf("Error ProbeEntropy failing source got %v want ErrRandomSource", err)
+	}
+}
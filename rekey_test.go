@@ -0,0 +1,43 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestUpsize(t *testing.T) {
+
+	smallPub, smallPriv, err := gaillier.GenerateKeyPair(rand.Reader, 256)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	largePub, largePriv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	// close to the old n, fits the new one
+	m := new(big.Int).Sub(smallPub.N, big.NewInt(1))
+	c, _ := gaillier.Encrypt(smallPub, m.Bytes())
+
+	up, err := gaillier.Upsize(smallPriv, largePub, c)
+	if err != nil {
+		t.Fatalf("Upsize failed %v", err)
+	}
+	if d, _ := gaillier.Decrypt(largePriv, up); new(big.Int).SetBytes(d).Cmp(m) != 0 {
+		t.Errorf("Error Upsize got %v want %v", new(big.Int).SetBytes(d), m)
+	}
+
+	// a plaintext above the small n can't move down
+	large := new(big.Int).Add(smallPub.N, big.NewInt(1))
+	c, _ = gaillier.Encrypt(largePub, large.Bytes())
+	if _, err := gaillier.Upsize(largePriv, smallPub, c); !errors.Is(err, gaillier.ErrLongMessage) {
+		t.Errorf("Error downsize of a large plaintext got %v want ErrLongMessage", err)
+	}
+}
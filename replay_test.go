@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestReplayGuard(t *testing.T) {
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	ciphers := make([][]byte, 4)
+	for i := range ciphers {
+		if ciphers[i], err = gaillier.Encrypt(pub, big.NewInt(int64(i)).Bytes()); err != nil {
+			t.Fatalf("Failed to encrypt %v", err)
+		}
+	}
+
+	g := gaillier.NewReplayGuard(2)
+
+	if g.Seen(ciphers[0]) {
+		t.Errorf("First submission reported as replay")
+	}
+	if !g.Seen(ciphers[0]) {
+		t.Errorf("Duplicate submission not detected")
+	}
+	if !g.Seen(append([]byte(nil), ciphers[0]...)) {
+		t.Errorf("Duplicate copy not detected")
+	}
+
+	//0 is the most recent, seeing 1 then 2 evicts 0
+	g.Seen(ciphers[1])
+	g.Seen(ciphers[2])
+	if g.Len() != 2 {
+		t.Errorf("Guard holds %d fingerprints want 2", g.Len())
+	}
+	if g.Seen(ciphers[0]) {
+		t.Errorf("Evicted cipher still reported as replay")
+	}
+
+	//0 & 2 are now remembered, a hit refreshes 2 so 0 is evicted next
+	if !g.Seen(ciphers[2]) {
+		t.Errorf("Recent cipher not detected")
+	}
+	g.Seen(ciphers[3])
+	if !g.Seen(ciphers[2]) {
+		t.Errorf("Refreshed cipher was evicted")
+	}
+
+	//re-randomized ciphers are not caught, by design
+	rerand := gaillier.Add(pub, ciphers[3], mustEncrypt(t, pub, nil))
+	if g.Seen(rerand) {
+		t.Errorf("Re-randomized cipher unexpectedly detected")
+	}
+}
+
+func TestReplayGuardConcurrent(t *testing.T) {
+
+	g := gaillier.NewReplayGuard(1000)
+	var dup int64
+	var wg sync.WaitGroup
+
+	//every goroutine submits the same 100 values, only the first of each is fresh
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if g.Seen(big.NewInt(int64(i)).Bytes()) {
+					atomic.AddInt64(&dup, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if dup != 7*100 {
+		t.Errorf("Got %d duplicates want %d", dup, 7*100)
+	}
+}
+
+func mustEncrypt(t *testing.T, pub *gaillier.PubKey, m []byte) []byte {
+	c, err := gaillier.Encrypt(pub, m)
+	if err != nil {
+		t.Fatalf("Failed to encrypt %v", err)
+	}
+	return c
+}
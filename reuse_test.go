@@ -0,0 +1,52 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestReuseDetection(t *testing.T) {
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	other, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	r := big.NewInt(12345)
+
+	// off by default
+	gaillier.EncryptWithRandomness(pub, []byte{1}, r)
+	if _, err := gaillier.EncryptWithRandomness(pub, []byte{2}, r); err != nil {
+		t.Errorf("Error reuse detected while detection is off %v", err)
+	}
+
+	gaillier.SetReuseDetection(1024)
+	defer gaillier.SetReuseDetection(0)
+
+	if _, err := gaillier.EncryptWithRandomness(pub, []byte{1}, r); err != nil {
+		t.Fatalf("Error first use of r failed %v", err)
+	}
+	if _, err := gaillier.EncryptWithRandomness(other, []byte{1}, r); err != nil {
+		t.Errorf("Error same r under another key failed %v", err)
+	}
+	if _, err := gaillier.EncryptWithRandomness(pub, []byte{2}, r); !errors.Is(err, gaillier.ErrInvalidRandomness) {
+		t.Errorf("Error reused r got %v want ErrInvalidRandomness", err)
+	}
+
+	// fresh randomness never trips it
+	for i := 0; i < 50; i++ {
+		if _, err := gaillier.Encrypt(pub, []byte{1}); err != nil {
+			t.Fatalf("Error Encrypt with detection on failed %v", err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestDecryptAndScrub(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	for _, v := range []int64{0, 1, 42, 1 << 40} {
+		c, _ := gaillier.Encrypt(pub, big.NewInt(v).Bytes())
+
+		d, err := gaillier.DecryptAndScrub(priv, c)
+		if err != nil {
+			t.Fatalf("DecryptAndScrub failed %v", err)
+		}
+		if got := new(big.Int).SetBytes(d); got.Int64() != v {
+			t.Errorf("Error DecryptAndScrub got %v want %v", got, v)
+		}
+	}
+
+	// the key is left intact
+	c, _ := gaillier.Encrypt(pub, []byte{9})
+	if d, _ := gaillier.Decrypt(priv, c); new(big.Int).SetBytes(d).Int64() != 9 {
+		t.Errorf("Error Decrypt after DecryptAndScrub got %v want 9", d)
+	}
+}
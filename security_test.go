@@ -0,0 +1,48 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestSecurityLevelBits(t *testing.T) {
+
+	keyOfSize := func(bits int) *gaillier.PubKey {
+		n := new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+		pub, err := gaillier.NewPubKeyFromN(n.Add(n, big.NewInt(1)))
+		if err != nil {
+			t.Fatalf("Error building a %d bit key %v", bits, err)
+		}
+		return pub
+	}
+
+	for _, tc := range []struct{ modulus, level int }{
+		{512, 40}, {1024, 80}, {2048, 112}, {3072, 128}, {4096, 142},
+		{7680, 192}, {15360, 256}, {20000, 256},
+	} {
+		if got := keyOfSize(tc.modulus).SecurityLevelBits(); got != tc.level {
+			t.Errorf("Error SecurityLevelBits(%d) got %v want %v", tc.modulus, got, tc.level)
+		}
+	}
+
+	for _, tc := range []struct{ level, modulus int }{
+		{80, 1024}, {112, 2048}, {128, 3072}, {192, 7680}, {256, 15360}, {257, 0},
+	} {
+		if got := gaillier.MinKeySizeForSecurity(tc.level); got != tc.modulus {
+			t.Errorf("Error MinKeySizeForSecurity(%d) got %v want %v", tc.level, got, tc.modulus)
+		}
+	}
+
+	//the inverse is the smallest size reaching the level
+	for level := 1; level <= 256; level++ {
+		bits := gaillier.MinKeySizeForSecurity(level)
+		if got := keyOfSize(bits).SecurityLevelBits(); got < level {
+			t.Errorf("Error %d bits for level %d only reach %d", bits, level, got)
+		}
+		if got := keyOfSize(bits - 1).SecurityLevelBits(); got >= level {
+			t.Errorf("Error %d bits for level %d is not the smallest size", bits, level)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestSelfTest(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		if err := gaillier.SelfTest(); err != nil {
+			t.Errorf("SelfTest failed %v", err)
+		}
+	}
+}
+
+func TestConsistencyCheck(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	if err := gaillier.ConsistencyCheck(priv); err != nil {
+		t.Errorf("Error ConsistencyCheck failed on a valid key %v", err)
+	}
+
+	// a backend computing r^(n+1) instead of r^n breaks encryption
+	prev := gaillier.SetModExp(func(base, exp, mod *big.Int) *big.Int {
+		if exp.Cmp(pub.N) == 0 {
+			exp = new(big.Int).Add(exp, big.NewInt(1))
+		}
+		return new(big.Int).Exp(base, exp, mod)
+	})
+	defer gaillier.SetModExp(prev)
+
+	if err := gaillier.ConsistencyCheck(priv); !errors.Is(err, gaillier.ErrSelfTest) {
+		t.Errorf("Error ConsistencyCheck with a faulty ModExp got %v want ErrSelfTest", err)
+	}
+}
+
+func TestEncryptTrivial(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	c, err := gaillier.EncryptTrivial(pub, []byte{42})
+	if err != nil {
+		t.Fatalf("EncryptTrivial failed %v", err)
+	}
+	if d, _ := gaillier.Decrypt(priv, c); new(big.Int).SetBytes(d).Int64() != 42 {
+		t.Errorf("Error EncryptTrivial got %v want 42", d)
+	}
+	if again, _ := gaillier.EncryptTrivial(pub, []byte{42}); !bytes.Equal(c, again) {
+		t.Errorf("Error EncryptTrivial is not deterministic")
+	}
+}
+
+func TestIsTrivialEncryption(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	for _, m := range [][]byte{nil, {1}, {42}} {
+		trivial, _ := gaillier.EncryptTrivial(pub, m)
+		if ok, err := priv.IsTrivialEncryption(trivial); err != nil || !ok {
+			t.Errorf("Error trivial encryption of %v not detected (%v)", m, err)
+		}
+
+		random, _ := gaillier.Encrypt(pub, m)
+		if ok, err := priv.IsTrivialEncryption(random); err != nil || ok {
+			t.Errorf("Error randomized encryption of %v reported trivial (%v)", m, err)
+		}
+
+		// blinding a trivial cipher makes it non trivial
+		blinded, _ := gaillier.ReRandomize(pub, trivial)
+		if ok, _ := priv.IsTrivialEncryption(blinded); ok {
+			t.Errorf("Error re-randomized trivial encryption of %v reported trivial", m)
+		}
+	}
+}
+
+func TestCheckRuntime(t *testing.T) {
+
+	if err := gaillier.CheckRuntime(); err != nil {
+		t.Fatalf("Error CheckRuntime failed on this toolchain %v", err)
+	}
+
+	//an exponentiation off by one for some inputs must be caught
+	prev := gaillier.SetModExp(func(base, exp, mod *big.Int) *big.Int {
+		res := new(big.Int).Exp(base, exp, mod)
+		if mod.BitLen() > 2000 {
+			res.Add(res, big.NewInt(1)).Mod(res, mod)
+		}
+		return res
+	})
+	defer gaillier.SetModExp(prev)
+
+	if err := gaillier.CheckRuntime(); !errors.Is(err, gaillier.ErrSelfTest) {
+		t.Errorf("Error CheckRuntime with a faulty Exp got %v want ErrSelfTest", err)
+	}
+
+	//a carry bug only showing on base m - 1, which pseudo random operands never hit
+	gaillier.SetModExp(func(base, exp, mod *big.Int) *big.Int {
+		res := new(big.Int).Exp(base, exp, mod)
+		if new(big.Int).Add(base, big.NewInt(1)).Cmp(mod) == 0 {
+			res.Add(res, big.NewInt(1)).Mod(res, mod)
+		}
+		return res
+	})
+	if err := gaillier.CheckRuntime(); !errors.Is(err, gaillier.ErrSelfTest) {
+		t.Errorf("Error CheckRuntime with an edge case Exp bug got %v want ErrSelfTest", err)
+	}
+}
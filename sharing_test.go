@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestShareAdditive(t *testing.T) {
+
+	m := new(big.Int).SetInt64(123456)
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	shares, err := gaillier.ShareAdditive(pub, m.Bytes(), 5)
+	if err != nil {
+		t.Fatalf("Failed to share message %v", err)
+	}
+	if len(shares) != 5 {
+		t.Fatalf("Got %d shares want 5", len(shares))
+	}
+
+	//reconstruction
+	d, err := gaillier.Decrypt(priv, gaillier.Sum(pub, shares))
+	if err != nil {
+		t.Errorf("Failed to decrypt sum of shares %v", err)
+	}
+	if got := new(big.Int).SetBytes(d); got.Cmp(m) != 0 {
+		t.Errorf("Error reconstructing shares got %v want %v", got, m)
+	}
+
+	//individual shares should look random : never the message, all distinct
+	//and spread over the whole ring rather than small values
+	seen := make(map[string]bool)
+	for i, s := range shares {
+		d, err := gaillier.Decrypt(priv, s)
+		if err != nil {
+			t.Fatalf("Failed to decrypt share %d %v", i, err)
+		}
+		v := new(big.Int).SetBytes(d)
+		if v.Cmp(m) == 0 {
+			t.Errorf("Share %d decrypts to the message", i)
+		}
+		if v.BitLen() < 256 {
+			t.Errorf("Share %d is suspiciously small %v", i, v)
+		}
+		if seen[v.String()] {
+			t.Errorf("Share %d repeats a previous share", i)
+		}
+		seen[v.String()] = true
+	}
+
+	//single share is the message itself
+	one, err := gaillier.ShareAdditive(pub, m.Bytes(), 1)
+	if err != nil {
+		t.Fatalf("Failed to share message %v", err)
+	}
+	d, _ = gaillier.Decrypt(priv, one[0])
+	if got := new(big.Int).SetBytes(d); got.Cmp(m) != 0 {
+		t.Errorf("Error with single share got %v want %v", got, m)
+	}
+
+	if _, err := gaillier.ShareAdditive(pub, m.Bytes(), 0); err != gaillier.ErrInvalidShareCount {
+		t.Errorf("Sharing into 0 shares got %v want %v", err, gaillier.ErrInvalidShareCount)
+	}
+}
+
+func TestZeroSumMasks(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	values := []int64{12, 0, 7, 30, 1}
+	masks, err := gaillier.GenerateZeroSumMasks(len(values), pub.N)
+	if err != nil {
+		t.Fatalf("Failed to generate masks %v", err)
+	}
+
+	total := new(big.Int)
+	for _, m := range masks {
+		total.Add(total, m)
+	}
+	if total.Mod(total, pub.N).Sign() != 0 {
+		t.Errorf("Error masks sum to %v want 0 mod n", total)
+	}
+
+	ciphers := make([][]byte, len(values))
+	for i, v := range values {
+		ciphers[i], err = gaillier.EncryptWithMask(pub, big.NewInt(v).Bytes(), masks[i].Bytes())
+		if err != nil {
+			t.Fatalf("Failed to encrypt masked value %v", err)
+		}
+	}
+
+	//a single masked value doesn't decrypt to the value
+	d, _ := gaillier.Decrypt(priv, ciphers[0])
+	if new(big.Int).SetBytes(d).Cmp(big.NewInt(values[0])) == 0 {
+		t.Errorf("Error masked cipher decrypts to the clear value")
+	}
+
+	d, err = gaillier.Decrypt(priv, gaillier.Sum(pub, ciphers))
+	if err != nil {
+		t.Fatalf("Failed to decrypt sum %v", err)
+	}
+	if got := new(big.Int).SetBytes(d).Int64(); got != 50 {
+		t.Errorf("Error masked sum got %v want 50", got)
+	}
+
+	if _, err := gaillier.GenerateZeroSumMasks(0, pub.N); err != gaillier.ErrInvalidShareCount {
+		t.Errorf("Error zero masks got %v want %v", err, gaillier.ErrInvalidShareCount)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestDecryptSigned(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	//n is odd so n/2 rounds down to (n-1)/2, the largest positive value
+	half := new(big.Int).Rsh(pub.N, 1)
+	large := new(big.Int).Lsh(big.NewInt(1), 400)
+
+	for _, m := range []*big.Int{
+		big.NewInt(0),
+		big.NewInt(-1),
+		large,
+		new(big.Int).Neg(large),
+		half,
+		new(big.Int).Neg(half),
+	} {
+		c, err := gaillier.EncryptSigned(pub, m)
+		if err != nil {
+			t.Fatalf("Failed to encrypt %v : %v", m, err)
+		}
+		got, err := gaillier.DecryptSigned(priv, c)
+		if err != nil || got.Cmp(m) != 0 {
+			t.Errorf("Signed round trip got %v want %v (%v)", got, m, err)
+		}
+	}
+
+	//boundary on raw plaintexts : (n-1)/2 stays positive, (n+1)/2 wraps to -(n-1)/2
+	c, _ := gaillier.Encrypt(pub, half.Bytes())
+	if got, _ := gaillier.DecryptSigned(priv, c); got.Cmp(half) != 0 {
+		t.Errorf("Plaintext (n-1)/2 decoded as %v want %v", got, half)
+	}
+	above := new(big.Int).Add(half, big.NewInt(1))
+	c, _ = gaillier.Encrypt(pub, above.Bytes())
+	if got, _ := gaillier.DecryptSigned(priv, c); got.Cmp(new(big.Int).Neg(half)) != 0 {
+		t.Errorf("Plaintext (n+1)/2 decoded as %v want %v", got, new(big.Int).Neg(half))
+	}
+
+	//out of range values
+	for _, m := range []*big.Int{new(big.Int).Add(half, big.NewInt(1)), new(big.Int).Neg(above)} {
+		if _, err := gaillier.EncryptSigned(pub, m); err != gaillier.ErrLongMessage {
+			t.Errorf("EncryptSigned(%v) got %v want %v", m, err, gaillier.ErrLongMessage)
+		}
+	}
+
+	//homomorphic sum crossing zero
+	a, _ := gaillier.EncryptSigned(pub, big.NewInt(-1000))
+	b, _ := gaillier.EncryptSigned(pub, big.NewInt(250))
+	if got, _ := gaillier.DecryptSigned(priv, gaillier.Add(pub, a, b)); got.Cmp(big.NewInt(-750)) != 0 {
+		t.Errorf("Signed addition got %v want -750", got)
+	}
+}
+
+func TestDecryptAddSigned(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	a, _ := gaillier.EncryptSigned(pub, big.NewInt(-10))
+	b, _ := gaillier.EncryptSigned(pub, big.NewInt(4))
+	c, _ := gaillier.EncryptSigned(pub, big.NewInt(1))
+
+	if got, _ := gaillier.DecryptAddSigned(priv, a, b, c); got.Int64() != -5 {
+		t.Errorf("Error DecryptAddSigned got %v want -5", got)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestEncryptedVariance(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	// mean 5, population variance 4
+	data := []int64{2, 4, 4, 4, 5, 5, 7, 9}
+
+	enc := gaillier.NewStatsEncoder(pub)
+	var xs, x2s [][]byte
+	for _, x := range data {
+		cx, cx2, err := enc.EncryptPair(x)
+		if err != nil {
+			t.Fatalf("EncryptPair failed %v", err)
+		}
+		xs, x2s = append(xs, cx), append(x2s, cx2)
+	}
+
+	sumX, sumX2, err := gaillier.SumPairs(pub, xs, x2s)
+	if err != nil {
+		t.Fatalf("SumPairs failed %v", err)
+	}
+
+	mean, variance, err := gaillier.DecryptMoments(priv, len(data), sumX, sumX2)
+	if err != nil {
+		t.Fatalf("DecryptMoments failed %v", err)
+	}
+	if mean.Cmp(big.NewRat(5, 1)) != 0 || variance.Cmp(big.NewRat(4, 1)) != 0 {
+		t.Errorf("Error moments got mean %v variance %v want 5 and 4", mean, variance)
+	}
+
+	// negative values {-3, 2} : mean -1/2, variance 25/4
+	xs, x2s = nil, nil
+	for _, x := range []int64{-3, 2} {
+		cx, cx2, _ := enc.EncryptPair(x)
+		xs, x2s = append(xs, cx), append(x2s, cx2)
+	}
+	sumX, sumX2, _ = gaillier.SumPairs(pub, xs, x2s)
+	mean, variance, _ = gaillier.DecryptMoments(priv, 2, sumX, sumX2)
+	if mean.Cmp(big.NewRat(-1, 2)) != 0 || variance.Cmp(big.NewRat(25, 4)) != 0 {
+		t.Errorf("Error moments got mean %v variance %v want -1/2 and 25/4", mean, variance)
+	}
+}
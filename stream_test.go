@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestCiphertextStream(t *testing.T) {
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	ciphers := append(encryptRange(t, pub, 10), []byte{})
+
+	buf := new(bytes.Buffer)
+	w := gaillier.NewCiphertextWriter(buf)
+	for _, c := range ciphers {
+		if err := w.Write(c); err != nil {
+			t.Fatalf("Write failed %v", err)
+		}
+	}
+
+	r := gaillier.NewCiphertextReader(bytes.NewReader(buf.Bytes()))
+	for i, want := range ciphers {
+		got, err := r.Read()
+		if err != nil {
+			t.Fatalf("Read %d failed %v", i, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("Error cipher %d read back differently", i)
+		}
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("Error end of stream got %v want io.EOF", err)
+	}
+}
+
+func TestCiphertextStreamTruncated(t *testing.T) {
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	buf := new(bytes.Buffer)
+	gaillier.NewCiphertextWriter(buf).Write(mustEncrypt(t, pub, []byte{1}))
+	data := buf.Bytes()
+
+	for _, cut := range []int{2, len(data) - 1} {
+		_, err := gaillier.NewCiphertextReader(bytes.NewReader(data[:cut])).Read()
+		if !errors.Is(err, gaillier.ErrInvalidEncoding) || !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("Error truncated frame at %d got %v want ErrInvalidEncoding", cut, err)
+		}
+	}
+}
+
+func TestStreamSum(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	const count = 200
+	buf := new(bytes.Buffer)
+	w := gaillier.NewCiphertextWriter(buf)
+	for _, c := range encryptRange(t, pub, count) {
+		w.Write(c)
+	}
+	data := buf.Bytes()
+
+	sum, err := gaillier.StreamSum(pub, bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("StreamSum failed %v", err)
+	}
+	if d, _ := gaillier.Decrypt(priv, sum); new(big.Int).SetBytes(d).Int64() != count*(count-1)/2 {
+		t.Errorf("Error StreamSum got %v want %v", new(big.Int).SetBytes(d), count*(count-1)/2)
+	}
+
+	if _, err := gaillier.StreamSum(pub, bytes.NewReader(data[:len(data)-3])); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+		t.Errorf("Error StreamSum of a truncated stream got %v want ErrInvalidEncoding", err)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestEncryptWithAAD(t *testing.T) {
+
+	macKey := []byte("0123456789abcdef0123456789abcdef")
+	aad := []byte("ballot-2026")
+	m := new(big.Int).SetInt64(77)
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	tc, err := gaillier.EncryptWithAAD(pub, macKey, m.Bytes(), aad)
+	if err != nil {
+		t.Errorf("Failed to encrypt with aad %v", err)
+	}
+
+	dec, err := gaillier.OpenTagged(priv, macKey, tc, aad)
+	if err != nil {
+		t.Errorf("Failed to open tagged cipher %v", err)
+	}
+	if new(big.Int).SetBytes(dec).Cmp(m) != 0 {
+		t.Errorf("Error opening tagged cipher got %v want %v", new(big.Int).SetBytes(dec), m)
+	}
+
+	//wrong context
+	if _, err := gaillier.OpenTagged(priv, macKey, tc, []byte("ballot-2027")); err != gaillier.ErrTagMismatch {
+		t.Errorf("Opening with wrong aad got %v want %v", err, gaillier.ErrTagMismatch)
+	}
+
+	//tampered cipher
+	tampered := &gaillier.TaggedCiphertext{Cipher: gaillier.Add(pub, tc.Cipher, tc.Cipher), Tag: tc.Tag}
+	if _, err := gaillier.OpenTagged(priv, macKey, tampered, aad); err != gaillier.ErrTagMismatch {
+		t.Errorf("Opening tampered cipher got %v want %v", err, gaillier.ErrTagMismatch)
+	}
+
+	//wrong mac key
+	if _, err := gaillier.OpenTagged(priv, []byte("wrong key"), tc, aad); err != gaillier.ErrTagMismatch {
+		t.Errorf("Opening with wrong mac key got %v want %v", err, gaillier.ErrTagMismatch)
+	}
+}
+
+func mustSeal(t *testing.T, cipher []byte, keyFP string, macKey []byte) []byte {
+	blob, err := gaillier.SealCiphertext(cipher, keyFP, macKey)
+	if err != nil {
+		t.Fatalf("Error SealCiphertext %v", err)
+	}
+	return blob
+}
+
+func TestSealCiphertext(t *testing.T) {
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	other, _, _ := gaillier.GenerateKeyPair(rand.Reader, 512)
+	macKey := []byte("server secret")
+	c := mustEncrypt(t, pub, []byte{42})
+
+	blob := mustSeal(t, c, pub.Fingerprint(), macKey)
+	cipher, fp, err := gaillier.OpenCiphertext(blob, macKey)
+	if err != nil {
+		t.Fatalf("Error OpenCiphertext %v", err)
+	}
+	if !bytes.Equal(cipher, c) || fp != pub.Fingerprint() {
+		t.Errorf("Error OpenCiphertext round trip mismatch")
+	}
+
+	//swapping either field, or flipping a bit anywhere, breaks the tag
+	swappedKey := mustSeal(t, c, other.Fingerprint(), []byte("attacker key"))
+	swapped := append(append([]byte(nil), blob[:4+len(c)+2]...), other.Fingerprint()...)
+	swapped = append(swapped, blob[len(blob)-32:]...)
+	otherCipher := mustEncrypt(t, pub, []byte{43})
+	swappedCipher := append(mustSeal(t, otherCipher, pub.Fingerprint(), macKey)[:4+len(otherCipher)], blob[4+len(c):]...)
+
+	tampered := map[string][]byte{
+		"swapped fingerprint": swapped,
+		"swapped cipher":      swappedCipher,
+		"foreign mac key":     swappedKey,
+	}
+	for _, i := range []int{5, 4 + len(c) + 3, len(blob) - 1} {
+		b := append([]byte(nil), blob...)
+		b[i] ^= 1
+		tampered[fmt.Sprintf("bit flip at %d", i)] = b
+	}
+	for name, b := range tampered {
+		if _, _, err := gaillier.OpenCiphertext(b, macKey); !errors.Is(err, gaillier.ErrTagMismatch) {
+			t.Errorf("Error %s got %v want ErrTagMismatch", name, err)
+		}
+	}
+
+	for name, b := range map[string][]byte{"empty": nil, "truncated": blob[:len(blob)-1], "long length": append([]byte{0xff, 0xff, 0xff, 0xff}, blob[4:]...)} {
+		if _, _, err := gaillier.OpenCiphertext(b, macKey); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+			t.Errorf("Error %s blob got %v want ErrInvalidEncoding", name, err)
+		}
+	}
+
+	//a fingerprint past the 2 byte length field is refused instead of truncated
+	if _, err := gaillier.SealCiphertext(c, strings.Repeat("f", math.MaxUint16+1), macKey); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+		t.Errorf("Error sealing an oversized fingerprint got %v want ErrInvalidEncoding", err)
+	}
+	long := mustSeal(t, c, strings.Repeat("f", math.MaxUint16), macKey)
+	if _, fp, err := gaillier.OpenCiphertext(long, macKey); err != nil || len(fp) != math.MaxUint16 {
+		t.Errorf("Error longest fingerprint round trip got %d bytes (%v)", len(fp), err)
+	}
+}
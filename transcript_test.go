@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestReplayTranscript(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	inputs := [][]byte{mustEncrypt(t, pub, []byte{7}), mustEncrypt(t, pub, []byte{5}), mustEncrypt(t, pub, []byte{2})}
+
+	// server side : (7 + 5) * 3 + 10 - 2
+	sum := gaillier.Add(pub, inputs[0], inputs[1])
+	scaled := gaillier.Mul(pub, sum, big.NewInt(3).Bytes())
+	shifted := gaillier.AddConstant(pub, scaled, big.NewInt(10).Bytes())
+	server := gaillier.Sub(pub, shifted, inputs[2])
+
+	ops := []gaillier.Op{
+		{Kind: gaillier.OpAdd, A: 0, B: 1},
+		{Kind: gaillier.OpMul, A: 3, Constant: big.NewInt(3).Bytes()},
+		{Kind: gaillier.OpAddConstant, A: 4, Constant: big.NewInt(10).Bytes()},
+		{Kind: gaillier.OpSub, A: 5, B: 2},
+	}
+
+	got, err := gaillier.ReplayTranscript(pub, inputs, ops)
+	if err != nil {
+		t.Fatalf("ReplayTranscript failed %v", err)
+	}
+	if !bytes.Equal(got, server) {
+		t.Errorf("Error replayed cipher doesn't match the server output")
+	}
+	if d, _ := gaillier.Decrypt(priv, got); new(big.Int).SetBytes(d).Int64() != 44 {
+		t.Errorf("Error replayed cipher got %v want 44", new(big.Int).SetBytes(d))
+	}
+
+	// a tampered constant no longer matches
+	ops[1].Constant = big.NewInt(4).Bytes()
+	if got, _ := gaillier.ReplayTranscript(pub, inputs, ops); bytes.Equal(got, server) {
+		t.Errorf("Error tampered transcript matched the server output")
+	}
+
+	bad := []gaillier.Op{{Kind: gaillier.OpAdd, A: 0, B: 3}}
+	if _, err := gaillier.ReplayTranscript(pub, inputs, bad); !errors.Is(err, gaillier.ErrInvalidTranscript) {
+		t.Errorf("Error out of range operand got %v want ErrInvalidTranscript", err)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+// tss-lib's paillier key types, as found in a TSS ceremony's saved key data
+type tssPublicKey struct {
+	N *big.Int
+}
+
+type tssPrivateKey struct {
+	tssPublicKey
+	LambdaN, PhiN *big.Int
+	P, Q          *big.Int
+}
+
+func tssFixture(t *testing.T) (*tssPrivateKey, []byte) {
+
+	_, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	pMin := new(big.Int).Sub(priv.P, big.NewInt(1))
+	qMin := new(big.Int).Sub(priv.Q, big.NewInt(1))
+	phi := new(big.Int).Mul(pMin, qMin)
+	key := &tssPrivateKey{
+		tssPublicKey: tssPublicKey{N: priv.N},
+		LambdaN:      new(big.Int).Div(phi, new(big.Int).GCD(nil, nil, pMin, qMin)),
+		PhiN:         phi,
+		P:            priv.P,
+		Q:            priv.Q,
+	}
+	data, err := json.Marshal(key)
+	if err != nil {
+		t.Fatalf("Marshal fixture failed %v", err)
+	}
+
+	return key, data
+}
+
+func TestImportTSSPrivateKey(t *testing.T) {
+
+	fixture, data := tssFixture(t)
+
+	priv, err := gaillier.ImportTSSPrivateKey(data)
+	if err != nil {
+		t.Fatalf("ImportTSSPrivateKey failed %v", err)
+	}
+	if priv.L.Cmp(fixture.LambdaN) != 0 {
+		t.Errorf("Error imported L got %v want LambdaN %v", priv.L, fixture.LambdaN)
+	}
+	if priv.KeyLen != fixture.N.BitLen() || priv.PubKey.KeyLen != fixture.N.BitLen() {
+		t.Errorf("Error imported KeyLen got %d (public %d) want %d", priv.KeyLen, priv.PubKey.KeyLen, fixture.N.BitLen())
+	}
+
+	c, err := gaillier.Encrypt(priv.Public(), []byte{42})
+	if err != nil {
+		t.Fatalf("Encrypt failed %v", err)
+	}
+	if d, _ := gaillier.Decrypt(priv, c); !bytes.Equal(d, []byte{42}) {
+		t.Errorf("Error Decrypt with imported key got %v want 42", d)
+	}
+
+	//older tss-lib versions only store PhiN next to LambdaN, some tools only PhiN
+	phiOnly, _ := json.Marshal(map[string]*big.Int{"N": fixture.N, "PhiN": fixture.PhiN})
+	priv, err = gaillier.ImportTSSPrivateKey(phiOnly)
+	if err != nil {
+		t.Fatalf("ImportTSSPrivateKey PhiN only failed %v", err)
+	}
+	if d, _ := gaillier.Decrypt(priv, c); !bytes.Equal(d, []byte{42}) {
+		t.Errorf("Error Decrypt with PhiN key got %v want 42", d)
+	}
+
+	bad := *fixture
+	bad.LambdaN = new(big.Int).Add(fixture.LambdaN, big.NewInt(2))
+	badData, _ := json.Marshal(&bad)
+	if _, err := gaillier.ImportTSSPrivateKey(badData); !errors.Is(err, gaillier.ErrInvalidKey) {
+		t.Errorf("Error wrong LambdaN got %v want ErrInvalidKey", err)
+	}
+	if _, err := gaillier.ImportTSSPrivateKey([]byte("{")); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+		t.Errorf("Error malformed JSON got %v want ErrInvalidEncoding", err)
+	}
+}
+
+func TestExportTSSPrivateKey(t *testing.T) {
+
+	fixture, data := tssFixture(t)
+
+	priv, err := gaillier.ImportTSSPrivateKey(data)
+	if err != nil {
+		t.Fatalf("ImportTSSPrivateKey failed %v", err)
+	}
+
+	//without P & Q the factors are recovered from L
+	priv.P, priv.Q = nil, nil
+	out, err := gaillier.ExportTSSPrivateKey(priv)
+	if err != nil {
+		t.Fatalf("ExportTSSPrivateKey failed %v", err)
+	}
+
+	var got tssPrivateKey
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("Unmarshal export failed %v", err)
+	}
+	if got.N.Cmp(fixture.N) != 0 || got.LambdaN.Cmp(fixture.LambdaN) != 0 || got.PhiN.Cmp(fixture.PhiN) != 0 {
+		t.Errorf("Error exported key differs from the fixture")
+	}
+	if new(big.Int).Mul(got.P, got.Q).Cmp(fixture.N) != 0 {
+		t.Errorf("Error exported P*Q is not N")
+	}
+
+	_, general, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+	general.G = new(big.Int).Add(general.G, big.NewInt(1))
+	if _, err := gaillier.ExportTSSPrivateKey(general); !errors.Is(err, gaillier.ErrInvalidKey) {
+		t.Errorf("Error export with g != n+1 got %v want ErrInvalidKey", err)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestTaggedTypes(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	values := []struct {
+		tag byte
+		a   any
+		b   any
+		sum any
+	}{
+		{gaillier.TagRaw, []byte{0x01, 0x00}, []byte{0x20}, []byte{0x01, 0x20}},
+		{gaillier.TagInt64, int64(-42), int64(12), int64(-30)},
+		{gaillier.TagFixed, 1.25, -3.5, -2.25},
+	}
+
+	for _, v := range values {
+		ca, err := gaillier.EncryptTagged(pub, v.tag, v.a)
+		if err != nil {
+			t.Fatalf("EncryptTagged %#x failed %v", v.tag, err)
+		}
+		cb, _ := gaillier.EncryptTagged(pub, v.tag, v.b)
+
+		got, err := gaillier.DecodeTagged(priv, ca)
+		if err != nil {
+			t.Fatalf("DecodeTagged %#x failed %v", v.tag, err)
+		}
+		if !taggedEqual(got, v.a) {
+			t.Errorf("Error DecodeTagged got %v want %v", got, v.a)
+		}
+
+		sum, err := gaillier.AddTagged(pub, ca, cb)
+		if err != nil {
+			t.Fatalf("AddTagged %#x failed %v", v.tag, err)
+		}
+		if got, _ := gaillier.DecodeTagged(priv, sum); !taggedEqual(got, v.sum) {
+			t.Errorf("Error AddTagged got %v want %v", got, v.sum)
+		}
+	}
+}
+
+func TestTaggedMismatch(t *testing.T) {
+
+	pub, _, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	ci, _ := gaillier.EncryptTagged(pub, gaillier.TagInt64, int64(1))
+	cf, _ := gaillier.EncryptTagged(pub, gaillier.TagFixed, 1.0)
+
+	if _, err := gaillier.AddTagged(pub, ci, cf); !errors.Is(err, gaillier.ErrIncompatible) {
+		t.Errorf("Error AddTagged with mismatched tags got %v want ErrIncompatible", err)
+	}
+	if _, err := gaillier.EncryptTagged(pub, gaillier.TagInt64, "one"); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+		t.Errorf("Error EncryptTagged with wrong type got %v want ErrInvalidEncoding", err)
+	}
+	if _, err := gaillier.EncryptTagged(pub, 0xff, int64(1)); !errors.Is(err, gaillier.ErrInvalidEncoding) {
+		t.Errorf("Error EncryptTagged with unknown tag got %v want ErrInvalidEncoding", err)
+	}
+}
+
+func taggedEqual(a, b any) bool {
+	if x, ok := a.([]byte); ok {
+		y, ok := b.([]byte)
+		return ok && bytes.Equal(x, y)
+	}
+	return a == b
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+func TestEncryptUniform(t *testing.T) {
+
+	pub, priv, err := gaillier.GenerateKeyPair(rand.Reader, 512)
+
+	if err != nil {
+		t.Errorf("Error Generating Keypair")
+	}
+
+	size := pub.CiphertextSize()
+	messages := [][]byte{nil, {0}, {1}, {0, 0, 0, 7}, new(big.Int).Sub(pub.N, big.NewInt(1)).Bytes()}
+	for i := 0; i < 60; i++ {
+		m, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), uint(i*8)))
+		messages = append(messages, m.Bytes())
+	}
+
+	for _, m := range messages {
+		// many encryptions so that some ciphers have leading zero bytes
+		for j := 0; j < 4; j++ {
+			c, err := gaillier.EncryptUniform(pub, m)
+			if err != nil {
+				t.Fatalf("EncryptUniform failed %v", err)
+			}
+			if len(c) != size {
+				t.Errorf("Error EncryptUniform length got %v want %v", len(c), size)
+			}
+			d, _ := gaillier.Decrypt(priv, c)
+			if new(big.Int).SetBytes(d).Cmp(new(big.Int).SetBytes(m)) != 0 {
+				t.Errorf("Error EncryptUniform of %x decrypts to %x", m, d)
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/duncandean/gomorph/gaillier"
+)
+
+const vectorSeed = "gomorph test vectors v1"
+
+func TestRunTestVectors(t *testing.T) {
+
+	fixture, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture %v", err)
+	}
+
+	if err := gaillier.RunTestVectors(fixture); err != nil {
+		t.Errorf("Committed test vectors failed %v", err)
+	}
+
+	//the fixture must be reproducible from its seed
+	gen, err := gaillier.GenerateTestVectors([]byte(vectorSeed))
+	if err != nil {
+		t.Fatalf("Failed to generate test vectors %v", err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(fixture), gen) {
+		t.Errorf("Generated test vectors differ from testdata/vectors.json")
+	}
+
+	other, err := gaillier.GenerateTestVectors([]byte("another seed"))
+	if err != nil {
+		t.Fatalf("Failed to generate test vectors %v", err)
+	}
+	if bytes.Equal(other, gen) {
+		t.Errorf("Different seeds produced the same test vectors")
+	}
+	if err := gaillier.RunTestVectors(other); err != nil {
+		t.Errorf("Freshly generated test vectors failed %v", err)
+	}
+}
+
+func TestRunTestVectorsMismatch(t *testing.T) {
+
+	fixture, err := os.ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatalf("Failed to read fixture %v", err)
+	}
+
+	//flip the last hex digit of the first addition result
+	i := bytes.Index(fixture, []byte(`"additions"`))
+	j := i + bytes.Index(fixture[i:], []byte(`"c": "`)) + len(`"c": "`)
+	j += bytes.IndexByte(fixture[j:], '"') - 1
+	tampered := append([]byte(nil), fixture...)
+	if tampered[j] == '0' {
+		tampered[j] = '1'
+	} else {
+		tampered[j] = '0'
+	}
+
+	if err := gaillier.RunTestVectors(tampered); !errors.Is(err, gaillier.ErrTestVectorMismatch) {
+		t.Errorf("Tampered test vectors got %v want %v", err, gaillier.ErrTestVectorMismatch)
+	}
+}